@@ -0,0 +1,52 @@
+package flog
+
+import (
+	"testing"
+)
+
+func Test_allocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocation counts differ under -race")
+	}
+
+	l := newFlog(nopCloser{nopWriter{}}, LOG_LOCAL0|LOG_INFO, "bench")
+
+	if n := testing.AllocsPerRun(100, func() { l.Debug("filtered") }); n != 0 {
+		t.Errorf("filtered Expect:0, get:%v", n)
+	}
+	if n := testing.AllocsPerRun(100, func() { l.Info("emitted") }); n > 1 {
+		t.Errorf("emitted Expect:<=1, get:%v", n)
+	}
+	b := []byte("write")
+	if n := testing.AllocsPerRun(100, func() { l.Write(b) }); n > 1 {
+		t.Errorf("write Expect:<=1, get:%v", n)
+	}
+}
+
+func Benchmark_filtered(b *testing.B) {
+	l := newFlog(nopCloser{nopWriter{}}, LOG_LOCAL0|LOG_INFO, "bench")
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		l.Debug("benchmark message")
+	}
+}
+
+func Benchmark_emitted(b *testing.B) {
+	l := newFlog(nopCloser{nopWriter{}}, LOG_LOCAL0|LOG_INFO, "bench")
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		l.Info("benchmark message")
+	}
+}
+
+func Benchmark_write(b *testing.B) {
+	l := newFlog(nopCloser{nopWriter{}}, LOG_LOCAL0|LOG_INFO, "bench")
+	msg := []byte("benchmark message")
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		l.Write(msg)
+	}
+}
@@ -0,0 +1,276 @@
+package flog
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// Overflow 是异步队列满时的处理方式
+type Overflow int
+
+const (
+	// OverflowBlock 等待队列有空位
+	OverflowBlock Overflow = iota
+	// OverflowDropOldest 丢弃队列中最早的一条
+	OverflowDropOldest
+	// OverflowDropNew 丢弃当前这条
+	OverflowDropNew
+)
+
+// WithAsync 开启异步写入：格式化好的日志放入queueSize大小的队列，
+// 由一个后台goroutine合并写出，调用方不等待磁盘或网络。
+// Flush和Close会等待队列中的日志全部写完
+func WithAsync(queueSize int) Option {
+	return func(w *Flog) {
+		w.opts.async = queueSize
+	}
+}
+
+// WithAsyncSingleProducer 配合WithAsync使用，异步队列改用无锁环形队列代替channel。
+// 写入队列时总是持有日志的锁，所以仍然可以在多个goroutine中写日志，
+// 只有一个goroutine写日志时开销最小。OverflowDropOldest需要从队列头部丢弃，
+// 与它同时使用时仍使用channel
+func WithAsyncSingleProducer() Option {
+	return func(w *Flog) {
+		w.opts.asyncSPSC = true
+	}
+}
+
+// WithOverflow 设置异步队列满时的处理方式，默认OverflowBlock
+func WithOverflow(o Overflow) Option {
+	return func(w *Flog) {
+		w.opts.overflow = o
+	}
+}
+
+// 写入goroutine单次合并写入的上限
+const asyncMaxBatch = 64 * 1024
+
+type asyncRecord struct {
+	b     []byte
+	flush bool
+	// 不为nil时写完之前的日志后把结果发给done
+	done chan error
+}
+
+// asyncWriter 替换Flog.w，Write只把数据放入队列。
+// 底层输出只由写入goroutine在持有wmu时使用，
+// 其它地方操作底层文件前也要先获取wmu
+type asyncWriter struct {
+	next     io.WriteCloser
+	file     *fileWriter
+	ch       chan asyncRecord
+	ring     *spscRing // 不为nil时代替ch，见WithAsyncSingleProducer
+	done     chan struct{}
+	overflow Overflow
+	dropped  atomic.Uint64
+	wmu      sync.Mutex
+	err      error
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+func (w *Flog) startAsync() {
+	a := &asyncWriter{
+		next:     w.w,
+		file:     w.file,
+		done:     make(chan struct{}),
+		overflow: w.opts.overflow,
+	}
+	if w.opts.asyncSPSC && a.overflow != OverflowDropOldest {
+		a.ring = newSPSCRing(w.opts.async)
+	} else {
+		a.ch = make(chan asyncRecord, w.opts.async)
+	}
+	w.async = a
+	w.w = a
+
+	if a.ring != nil {
+		go a.runRing()
+	} else {
+		go a.run()
+	}
+}
+
+func (a *asyncWriter) run() {
+	defer close(a.done)
+
+	var buf []byte
+	for r := range a.ch {
+		// 把已经在队列中的日志合并成一次写入，遇到flush时停止
+		buf = append(buf[:0], r.b...)
+		for !r.flush && len(buf) < asyncMaxBatch {
+			var ok bool
+			select {
+			case r, ok = <-a.ch:
+			default:
+			}
+			if !ok {
+				break
+			}
+			buf = append(buf, r.b...)
+		}
+
+		a.finish(buf, r)
+	}
+}
+
+func (a *asyncWriter) runRing() {
+	defer close(a.done)
+
+	var buf []byte
+	for a.ring.wait() {
+		r, _ := a.ring.pop()
+		buf = append(buf[:0], r.b...)
+		for !r.flush && len(buf) < asyncMaxBatch {
+			next, ok := a.ring.pop()
+			if !ok {
+				break
+			}
+			r = next
+			buf = append(buf, r.b...)
+		}
+
+		a.finish(buf, r)
+	}
+}
+
+// finish 写出合并好的buf，r是其中最后一条
+func (a *asyncWriter) finish(buf []byte, r asyncRecord) {
+	a.wmu.Lock()
+	err := a.write(buf, r.flush)
+	a.wmu.Unlock()
+
+	if err != nil && a.err == nil {
+		a.err = err
+	}
+	if r.done != nil {
+		r.done <- err
+	}
+}
+
+func (a *asyncWriter) write(b []byte, flush bool) error {
+	if len(b) > 0 {
+		if _, err := a.next.Write(b); err != nil {
+			return err
+		}
+	}
+	if flush && a.file != nil {
+		return a.file.flush()
+	}
+	return nil
+}
+
+func (a *asyncWriter) Write(p []byte) (int, error) {
+	// p可能被调用方复用
+	if err := a.put(asyncRecord{b: append([]byte(nil), p...)}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (a *asyncWriter) put(r asyncRecord) error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.closed {
+		return ErrClosed
+	}
+
+	if a.ring != nil {
+		if r.flush || a.overflow != OverflowDropNew {
+			a.ring.push(r)
+		} else if !a.ring.tryPush(r) {
+			a.dropped.Add(1)
+		}
+		return nil
+	}
+
+	// flush不能丢弃
+	if r.flush {
+		a.ch <- r
+		return nil
+	}
+
+	switch a.overflow {
+	case OverflowDropNew:
+		select {
+		case a.ch <- r:
+		default:
+			a.dropped.Add(1)
+		}
+	case OverflowDropOldest:
+		for {
+			select {
+			case a.ch <- r:
+				return nil
+			default:
+			}
+			select {
+			case old := <-a.ch:
+				// Flush等待的标记不能丢弃，放回队列末尾，它之前的日志仍会先写出
+				if old.flush || old.done != nil {
+					a.ch <- old
+					continue
+				}
+				a.dropped.Add(1)
+			default:
+			}
+		}
+	default:
+		a.ch <- r
+	}
+	return nil
+}
+
+// drain 停止接收新日志并等待队列写完，返回写入时遇到的第一个错误
+func (a *asyncWriter) drain() error {
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		if a.ring != nil {
+			a.ring.close()
+		} else {
+			close(a.ch)
+		}
+	}
+	a.mu.Unlock()
+
+	<-a.done
+	return a.err
+}
+
+func (a *asyncWriter) Close() error {
+	err := a.drain()
+	if err1 := a.next.Close(); err == nil {
+		err = err1
+	}
+	return err
+}
+
+// lockFile 异步写入时获取底层输出的锁，返回解锁函数
+func (w *Flog) lockFile() func() {
+	if w.async == nil {
+		return func() {}
+	}
+	w.async.wmu.Lock()
+	return w.async.wmu.Unlock
+}
+
+// len 返回队列中等待写入的条数
+func (a *asyncWriter) len() int {
+	if a.ring != nil {
+		return a.ring.len()
+	}
+	return len(a.ch)
+}
+
+// Dropped 返回异步队列满时丢弃的日志条数
+func (w *Flog) Dropped() uint64 {
+	if w.async == nil {
+		return 0
+	}
+	return w.async.dropped.Load()
+}
@@ -0,0 +1,271 @@
+package flog
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// slowWriter 在release关闭前阻塞第一次写入
+type slowWriter struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	entered chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func newSlowWriter() *slowWriter {
+	return &slowWriter{entered: make(chan struct{}), release: make(chan struct{})}
+}
+
+func (s *slowWriter) Write(p []byte) (int, error) {
+	s.once.Do(func() { close(s.entered) })
+	<-s.release
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.Write(p)
+}
+
+func (s *slowWriter) Close() error {
+	return nil
+}
+
+func (s *slowWriter) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.String()
+}
+
+func newAsyncFlog(t *testing.T, o Overflow) (*Flog, *slowWriter) {
+	sw := newSlowWriter()
+	l, err := NewFromWriter(sw, LOG_LOCAL0|LOG_INFO, LOG_INFO, "test",
+		WithFormat(FormatCompact), WithAsync(2), WithOverflow(o))
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+
+	// 第一条被写入goroutine取走后阻塞，队列随后可以放入2条
+	l.Info("1")
+	<-sw.entered
+	return l, sw
+}
+
+func Test_asyncDropNew(t *testing.T) {
+	l, sw := newAsyncFlog(t, OverflowDropNew)
+
+	for _, m := range []string{"2", "3", "4", "5"} {
+		if err := l.Info(m); err != nil {
+			t.Errorf("Expect:nil, get:%v", err)
+		}
+	}
+	close(sw.release)
+	l.Close()
+
+	if s := sw.String(); s != "6|1\n6|2\n6|3\n" {
+		t.Errorf("Expect:%q, get:%q", "6|1\n6|2\n6|3\n", s)
+	}
+	if n := l.Dropped(); n != 2 {
+		t.Errorf("Expect:2, get:%d", n)
+	}
+}
+
+func Test_asyncDropOldest(t *testing.T) {
+	l, sw := newAsyncFlog(t, OverflowDropOldest)
+
+	for _, m := range []string{"2", "3", "4", "5"} {
+		l.Info(m)
+	}
+	close(sw.release)
+	l.Close()
+
+	if s := sw.String(); s != "6|1\n6|4\n6|5\n" {
+		t.Errorf("Expect:%q, get:%q", "6|1\n6|4\n6|5\n", s)
+	}
+	if n := l.Dropped(); n != 2 {
+		t.Errorf("Expect:2, get:%d", n)
+	}
+}
+
+func Test_asyncDropOldestFlush(t *testing.T) {
+	l, sw := newAsyncFlog(t, OverflowDropOldest)
+
+	l.Info("2")
+	flushed := make(chan error, 1)
+	go func() {
+		flushed <- l.Flush()
+	}()
+
+	// 等Flush的标记进入队列后继续写入，把标记挤到队首
+	deadline := time.Now().Add(2 * time.Second)
+	for len(l.async.ch) < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	for _, m := range []string{"3", "4", "5"} {
+		l.Info(m)
+	}
+	close(sw.release)
+
+	select {
+	case err := <-flushed:
+		if err != nil {
+			t.Errorf("Expect:nil, get:%v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Expect: Flush returns after marker evicted")
+	}
+	l.Close()
+
+	if s := sw.String(); !strings.HasPrefix(s, "6|1\n") || !strings.HasSuffix(s, "6|5\n") {
+		t.Errorf("Expect: first and last line kept, get:%q", s)
+	}
+}
+
+func Test_asyncBlock(t *testing.T) {
+	l, sw := newAsyncFlog(t, OverflowBlock)
+
+	l.Info("2")
+	l.Info("3")
+
+	returned := make(chan struct{})
+	go func() {
+		l.Info("4")
+		close(returned)
+	}()
+
+	select {
+	case <-returned:
+		t.Errorf("Expect: blocked while queue is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(sw.release)
+	<-returned
+	l.Close()
+
+	if s := sw.String(); s != "6|1\n6|2\n6|3\n6|4\n" {
+		t.Errorf("Expect:%q, get:%q", "6|1\n6|2\n6|3\n6|4\n", s)
+	}
+	if n := l.Dropped(); n != 0 {
+		t.Errorf("Expect:0, get:%d", n)
+	}
+	if err := l.Info("after"); err != ErrClosed {
+		t.Errorf("Expect:%v, get:%v", ErrClosed, err)
+	}
+}
+
+func Test_asyncConcurrent(t *testing.T) {
+	var buf syncBuffer
+	l, _ := NewFromWriter(nopCloser{&buf}, LOG_LOCAL0|LOG_INFO, LOG_INFO, "test",
+		WithFormat(FormatCompact), WithAsync(16))
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				l.Info("async")
+			}
+		}()
+	}
+	wg.Wait()
+	l.Close()
+
+	if n := bytes.Count([]byte(buf.String()), []byte("6|async\n")); n != 400 {
+		t.Errorf("Expect:400, get:%d", n)
+	}
+}
+
+func Test_asyncFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "async.log")
+
+	l, err := File(file, LOG_LOCAL0|LOG_INFO, "test", WithAsync(8), WithBuffer(4096, time.Millisecond), FlushOnError(true))
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	l.SetFormatter(CompactFormatter{})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			l.Err("async")
+		}
+	}()
+	for i := 0; i < 10; i++ {
+		l.Reopen()
+	}
+	wg.Wait()
+	l.Close()
+
+	if n := strings.Count(readFile(t, file), "3|async\n"); n != 100 {
+		t.Errorf("Expect:100, get:%d", n)
+	}
+}
+
+func Test_asyncFlush(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "async.log")
+
+	l, err := File(file, LOG_LOCAL0|LOG_INFO, "test", WithAsync(64), WithBuffer(4096, 0), WithOverflow(OverflowDropNew))
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	defer l.Close()
+	l.SetFormatter(CompactFormatter{})
+
+	for i := 0; i < 10; i++ {
+		l.Info("queued")
+	}
+	if err := l.Flush(); err != nil {
+		t.Errorf("Expect:nil, get:%v", err)
+	}
+
+	if n := strings.Count(readFile(t, file), "6|queued\n"); n != 10 {
+		t.Errorf("Expect:10, get:%d", n)
+	}
+}
+
+func Test_asyncBatch(t *testing.T) {
+	cw := &countingWriter{}
+	l, _ := NewFromWriter(cw, LOG_LOCAL0|LOG_INFO, LOG_INFO, "test", WithFormat(FormatCompact), WithAsync(64))
+
+	// 写入goroutine等待底层输出时，队列中积累的日志合并写入
+	l.async.wmu.Lock()
+	for i := 0; i < 20; i++ {
+		l.Info("batch")
+	}
+	l.async.wmu.Unlock()
+	l.Flush()
+
+	if n, data := cw.stats(); n > 2 || strings.Count(data, "6|batch\n") != 20 {
+		t.Errorf("Expect: 20 lines in at most 2 writes, get:%d writes %q", n, data)
+	}
+	l.Close()
+}
+
+func Test_flushSync(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "buffer.log")
+
+	l, err := File(file, LOG_LOCAL0|LOG_INFO, "test", WithBuffer(4096, 0))
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	l.SetFormatter(CompactFormatter{})
+
+	l.Info("buffered")
+	l.Flush()
+	if s := readFile(t, file); s != "6|buffered\n" {
+		t.Errorf("Expect:%q, get:%q", "6|buffered\n", s)
+	}
+
+	l.Close()
+	if err := l.Flush(); err != ErrClosed {
+		t.Errorf("Expect:%v, get:%v", ErrClosed, err)
+	}
+}
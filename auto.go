@@ -0,0 +1,63 @@
+package flog
+
+import (
+	"errors"
+	"os"
+	"strings"
+)
+
+// isTerminal 判断f是否是终端，测试时可以替换。
+// 没有引入x/term，同样用ioctl(windows上为GetConsoleMode)判断
+var isTerminal = isatty
+
+// NewAuto 根据运行环境创建日志：stderr是终端时输出带颜色的文本(见ConsoleFormatter)，
+// 否则(如在systemd、docker下运行)向stderr输出JSON。
+// 环境变量 FLOG_TARGET、FLOG_LEVEL、FLOG_FORMAT 可以覆盖目标、级别和格式
+func NewAuto(tag string) (Writer, error) {
+	target := os.Getenv("FLOG_TARGET")
+	level := os.Getenv("FLOG_LEVEL")
+	format := os.Getenv("FLOG_FORMAT")
+
+	if format == "" && (target == "" || target == "<stderr>") {
+		if isTerminal(os.Stderr) {
+			format = "console"
+		} else {
+			format = "json"
+		}
+	}
+
+	w, err := New(target, level, tag)
+	if err != nil || format == "" {
+		return w, err
+	}
+
+	l, ok := w.(*Flog)
+	if !ok {
+		return w, nil
+	}
+
+	f, err := formatterByName(format)
+	if err != nil {
+		return nil, err
+	}
+	l.SetFormatter(f)
+	return l, nil
+}
+
+func formatterByName(name string) (Formatter, error) {
+	switch strings.ToLower(name) {
+	case "human", "text":
+		return HumanFormatter{}, nil
+	case "syslog":
+		return SyslogFormatter{}, nil
+	case "compact":
+		return CompactFormatter{}, nil
+	case "json":
+		return NewJSONFormatter(), nil
+	case "rfc5424":
+		return NewRFC5424Formatter(), nil
+	case "console", "pretty":
+		return ConsoleFormatter{NoColor: os.Getenv("NO_COLOR") != ""}, nil
+	}
+	return nil, errors.New("flog: unknown format " + name)
+}
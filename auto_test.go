@@ -0,0 +1,66 @@
+package flog
+
+import (
+	"os"
+	"testing"
+)
+
+func Test_newAuto(t *testing.T) {
+	old := isTerminal
+	defer func() { isTerminal = old }()
+
+	t.Setenv("FLOG_TARGET", "")
+	t.Setenv("FLOG_LEVEL", "")
+	t.Setenv("FLOG_FORMAT", "")
+
+	tests := []struct {
+		tty    bool
+		format string
+	}{
+		{true, "console"},
+		{false, "json"},
+	}
+
+	for _, tt := range tests {
+		isTerminal = func(*os.File) bool { return tt.tty }
+
+		w, err := NewAuto("test")
+		if err != nil {
+			t.Fatalf("Expect:nil, get:%v", err)
+		}
+
+		l := w.(*Flog)
+		if l.w != os.Stderr {
+			t.Errorf("tty=%v Expect: stderr", tt.tty)
+		}
+		if f := formatterName(l.getFormatter()); f != tt.format {
+			t.Errorf("tty=%v Expect:%s, get:%s", tt.tty, tt.format, f)
+		}
+	}
+
+	t.Setenv("FLOG_TARGET", "<stdout>")
+	t.Setenv("FLOG_FORMAT", "compact")
+	t.Setenv("FLOG_LEVEL", "user:debug")
+
+	w, err := NewAuto("test")
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	l := w.(*Flog)
+	if l.w != os.Stdout || formatterName(l.getFormatter()) != "compact" || l.filter != LOG_DEBUG {
+		t.Errorf("Expect: env override, get:%v %s %v", l.w, formatterName(l.getFormatter()), l.filter)
+	}
+}
+
+func Test_isattyDevNull(t *testing.T) {
+	f, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer f.Close()
+
+	// /dev/null是字符设备，但不是终端
+	if isatty(f) {
+		t.Errorf("Expect: %s is not a terminal", os.DevNull)
+	}
+}
@@ -0,0 +1,95 @@
+package flog
+
+import (
+	"time"
+)
+
+// WithBuffer 文件写入先进入size字节的缓存，每隔flushEvery写出一次，
+// 缓存满时也会写出。开启后文件不再以O_SYNC打开，Close时写出剩余内容。
+// flushEvery为0时只在缓存满或Close时写出
+func WithBuffer(size int, flushEvery time.Duration) Option {
+	return func(w *Flog) {
+		w.opts.buffer = size
+		w.opts.flushEvery = flushEvery
+	}
+}
+
+// FlushOnError 开启后LOG_ERR及以上级别的日志写入后立即写出缓存，
+// 避免进程崩溃时丢失导致崩溃的错误日志
+func FlushOnError(on bool) Option {
+	return func(w *Flog) {
+		w.opts.flushOnErr = on
+	}
+}
+
+func (w *Flog) goFlush(interval time.Duration) {
+	w.flushing = true
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-t.C:
+			case <-w.done:
+				return
+			}
+			w.flushFile()
+		}
+	}()
+}
+
+func (w *Flog) flushFile() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed || w.file == nil {
+		return nil
+	}
+
+	defer w.lockFile()()
+	return w.file.flush()
+}
+
+// flushOn 按级别决定是否立即写出缓存，调用时必须持有mu
+func (w *Flog) flushOn(p Priority) error {
+	if !w.opts.flushOnErr || w.file == nil || p&severityMask > LOG_ERR {
+		return nil
+	}
+	if w.async != nil {
+		return w.async.put(asyncRecord{flush: true})
+	}
+	return w.file.flush()
+}
+
+// Flush 写出合并写入、异步队列和文件缓存中的日志，异步写入时等待写完
+func (w *Flog) Flush() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return ErrClosed
+	}
+
+	if c, ok := w.w.(*coalescer); ok {
+		if err := c.flush(); err != nil {
+			w.mu.Unlock()
+			return err
+		}
+	}
+
+	if w.async == nil {
+		defer w.mu.Unlock()
+		if w.file == nil {
+			return nil
+		}
+		return w.file.flush()
+	}
+
+	done := make(chan error, 1)
+	err := w.async.put(asyncRecord{flush: true, done: done})
+	w.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return <-done
+}
@@ -0,0 +1,84 @@
+package flog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_buffer(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "buffer.log")
+
+	l, err := File(file, LOG_LOCAL0|LOG_INFO, "test", WithBuffer(4096, 0), FlushOnError(true))
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	l.SetFormatter(CompactFormatter{})
+
+	l.Info("buffered")
+	if s := readFile(t, file); s != "" {
+		t.Errorf("Expect: empty before flush, get:%q", s)
+	}
+
+	// ERR强制写出
+	l.Err("error")
+	if s := readFile(t, file); s != "6|buffered\n3|error\n" {
+		t.Errorf("Expect: flushed on error, get:%q", s)
+	}
+
+	l.Info("last")
+	l.Close()
+	if s := readFile(t, file); s != "6|buffered\n3|error\n6|last\n" {
+		t.Errorf("Expect: flushed on close, get:%q", s)
+	}
+}
+
+func Test_bufferFlushEvery(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "buffer.log")
+
+	l, err := File(file, LOG_LOCAL0|LOG_INFO, "test", WithBuffer(4096, 10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	defer l.Close()
+	l.SetFormatter(CompactFormatter{})
+
+	l.Info("tick")
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if data, _ := os.ReadFile(file); string(data) == "6|tick\n" {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Errorf("Expect: flushed by background goroutine")
+}
+
+func Test_bufferRotate(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.log")
+
+	l, err := File(file, LOG_LOCAL0|LOG_INFO, "test", WithBuffer(4096, 0), WithMaxSize(20))
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	l.SetFormatter(CompactFormatter{})
+
+	for _, m := range []string{"line-0", "line-1", "line-2"} {
+		l.Info(m)
+	}
+	l.Close()
+
+	if s := readFile(t, file+".1"); s != "6|line-0\n6|line-1\n" {
+		t.Errorf("Expect: backup flushed before rotation, get:%q", s)
+	}
+	if s := readFile(t, file); s != "6|line-2\n" {
+		t.Errorf("Expect:%q, get:%q", "6|line-2\n", s)
+	}
+}
+
+func Benchmark_fileBuffered(b *testing.B) {
+	benchmarkFile(b, WithBuffer(64*1024, 100*time.Millisecond))
+}
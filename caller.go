@@ -0,0 +1,83 @@
+package flog
+
+import (
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// 本包函数名的前缀，如 "github.com/bybzmt/golang-filelog."
+var pkgPrefix = func() string {
+	name := runtime.FuncForPC(reflect.ValueOf(callerFields).Pointer()).Name()
+	return name[:strings.LastIndex(name, ".")+1]
+}()
+
+// SetReportCaller 开启后每条日志增加caller(文件名:行号)和func(函数名)两个字段。
+// 本包内的调用自动跳过，skip为调用方封装日志的函数层数，见WithCaller
+func (w *Flog) SetReportCaller(on bool, skip int) {
+	// 0表示关闭，其余为skip+1
+	var v int32
+	if on {
+		v = int32(skip) + 1
+	}
+	w.caller.Store(v)
+}
+
+// WithCaller 同SetReportCaller(true, skip)
+func WithCaller(skip int) Option {
+	return func(w *Flog) {
+		w.SetReportCaller(true, skip)
+	}
+}
+
+// addCaller 在r.Fields中追加调用位置
+func (w *Flog) addCaller(r *Record) {
+	skip := int(w.caller.Load()) - 1
+	if skip < 0 {
+		return
+	}
+
+	if f, ok := callerFrame(r.pc, skip); ok {
+		// 不修改调用方的字段
+		r.Fields = append(r.Fields[:len(r.Fields):len(r.Fields)], callerFields(f)...)
+	}
+}
+
+// callerFrame 返回跳过本包和skip层之后的调用位置，pc不为0时直接使用pc
+func callerFrame(pc uintptr, skip int) (runtime.Frame, bool) {
+	if pc != 0 {
+		f, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+		return f, f.PC != 0
+	}
+
+	var pcs [32]uintptr
+	n := runtime.Callers(2, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		f, more := frames.Next()
+		// 包内的测试代码算作调用方
+		inPkg := strings.HasPrefix(f.Function, pkgPrefix) && !strings.HasSuffix(f.File, "_test.go")
+		if !inPkg {
+			if skip == 0 {
+				return f, true
+			}
+			skip--
+		}
+		if !more {
+			return runtime.Frame{}, false
+		}
+	}
+}
+
+func callerFields(f runtime.Frame) []Field {
+	fn := f.Function
+	if i := strings.LastIndexByte(fn, '/'); i >= 0 {
+		fn = fn[i+1:]
+	}
+	return []Field{
+		{"caller", filepath.Base(f.File) + ":" + strconv.Itoa(f.Line)},
+		{"func", fn},
+	}
+}
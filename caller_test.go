@@ -0,0 +1,59 @@
+package flog
+
+import (
+	"bytes"
+	"log/slog"
+	"runtime"
+	"strconv"
+	"testing"
+)
+
+// logHelper 模拟调用方对日志的封装
+func logHelper(l *Flog, m string) {
+	l.Info(m)
+}
+
+func line() string {
+	_, _, n, _ := runtime.Caller(1)
+	return strconv.Itoa(n + 1)
+}
+
+func Test_reportCaller(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestFlog(&buf, LOG_LOCAL0|LOG_INFO, "test")
+	l.SetFormatter(CompactFormatter{})
+
+	l.SetReportCaller(true, 0)
+	n1 := line()
+	l.Info("direct")
+	n2 := line()
+	l.With("k", "v").Infof("entry %d", 1)
+	l.SetReportCaller(true, 1)
+	n3 := line()
+	logHelper(l, "helper")
+	l.SetReportCaller(false, 0)
+	l.Info("off")
+
+	expect := "6|direct caller=caller_test.go:" + n1 + " func=golang-filelog.Test_reportCaller\n" +
+		"6|entry 1 k=v caller=caller_test.go:" + n2 + " func=golang-filelog.Test_reportCaller\n" +
+		"6|helper caller=caller_test.go:" + n3 + " func=golang-filelog.Test_reportCaller\n" +
+		"6|off\n"
+	if buf.String() != expect {
+		t.Errorf("Expect:%q, get:%q", expect, buf.String())
+	}
+}
+
+func Test_reportCallerSlog(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestFlog(&buf, LOG_LOCAL0|LOG_INFO, "test")
+	l.SetFormatter(CompactFormatter{})
+	l.SetReportCaller(true, 0)
+
+	n := line()
+	slog.New(NewSlogHandler(l, nil)).Info("slog")
+
+	expect := "6|slog caller=caller_test.go:" + n + " func=golang-filelog.Test_reportCallerSlog\n"
+	if buf.String() != expect {
+		t.Errorf("Expect:%q, get:%q", expect, buf.String())
+	}
+}
@@ -0,0 +1,166 @@
+package flog
+
+import (
+	"sync"
+)
+
+type chanRecord struct {
+	p    Priority
+	msg  string
+	rec  *Record
+	line []byte
+	raw  bool
+}
+
+// ChannelWriter 把日志放入channel，由一个goroutine串行写入sink，
+// 高并发时调用方之间不再争用sink的锁。
+// sink为*Flog时在调用方goroutine中完成过滤和格式化，只把格式化好的行交给写入goroutine，
+// 限流、路由、统计和Hook与直接写sink相同
+type ChannelWriter struct {
+	sink Writer
+	flog *Flog
+	ch   chan chanRecord
+	done chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+	err    error
+}
+
+func NewChannelWriter(sink Writer, buffer int) *ChannelWriter {
+	c := &ChannelWriter{
+		sink: sink,
+		ch:   make(chan chanRecord, buffer),
+		done: make(chan struct{}),
+	}
+	c.flog, _ = sink.(*Flog)
+
+	go c.run()
+	return c
+}
+
+func (c *ChannelWriter) run() {
+	defer close(c.done)
+
+	for r := range c.ch {
+		var err error
+		switch {
+		case r.rec != nil:
+			var ok bool
+			_, ok, err = c.flog.outputLine(r.rec, r.line)
+			if hooks := c.flog.hooks.Load(); ok && hooks != nil {
+				c.flog.fire(*hooks, r.rec)
+			}
+		case r.raw:
+			_, err = c.sink.Write([]byte(r.msg))
+		default:
+			err = writeTo(c.sink, r.p, r.msg)
+		}
+		if err != nil {
+			c.err = err
+		}
+	}
+}
+
+func (c *ChannelWriter) send(r chanRecord) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.closed {
+		return ErrClosed
+	}
+
+	c.ch <- r
+	return nil
+}
+
+func (c *ChannelWriter) writeAndRetry(p Priority, m string) (int, error) {
+	r := chanRecord{p: p, msg: m}
+
+	var rec *Record
+	if c.flog != nil {
+		rec = &Record{Priority: p, Msg: m}
+		if !c.flog.resolve(rec) {
+			return 0, nil
+		}
+		r.p = rec.Priority
+		r.rec = rec
+		r.line = c.flog.format(nil, rec)
+	}
+
+	if err := c.send(r); err != nil {
+		return 0, err
+	}
+	return len(m), nil
+}
+
+func (c *ChannelWriter) Write(b []byte) (int, error) {
+	if c.flog != nil {
+		return c.writeAndRetry(c.flog.getPriority(), string(b))
+	}
+
+	if err := c.send(chanRecord{msg: string(b), raw: true}); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// Close 等待channel中的日志全部写完，然后关闭sink
+func (c *ChannelWriter) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	close(c.ch)
+	c.mu.Unlock()
+
+	<-c.done
+
+	err := c.sink.Close()
+	if c.err != nil {
+		err = c.err
+	}
+	return err
+}
+
+func (c *ChannelWriter) Emerg(m string) (err error) {
+	_, err = c.writeAndRetry(LOG_EMERG, m)
+	return err
+}
+
+func (c *ChannelWriter) Alert(m string) (err error) {
+	_, err = c.writeAndRetry(LOG_ALERT, m)
+	return err
+}
+
+func (c *ChannelWriter) Crit(m string) (err error) {
+	_, err = c.writeAndRetry(LOG_CRIT, m)
+	return err
+}
+
+func (c *ChannelWriter) Err(m string) (err error) {
+	_, err = c.writeAndRetry(LOG_ERR, m)
+	return err
+}
+
+func (c *ChannelWriter) Warning(m string) (err error) {
+	_, err = c.writeAndRetry(LOG_WARNING, m)
+	return err
+}
+
+func (c *ChannelWriter) Notice(m string) (err error) {
+	_, err = c.writeAndRetry(LOG_NOTICE, m)
+	return err
+}
+
+func (c *ChannelWriter) Info(m string) (err error) {
+	_, err = c.writeAndRetry(LOG_INFO, m)
+	return err
+}
+
+func (c *ChannelWriter) Debug(m string) (err error) {
+	_, err = c.writeAndRetry(LOG_DEBUG, m)
+	return err
+}
@@ -0,0 +1,124 @@
+package flog
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_channelWriter(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestFlog(&buf, LOG_LOCAL0|LOG_INFO, "test")
+	l.SetFormatter(CompactFormatter{})
+
+	c := NewChannelWriter(l, 16)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				c.Info(fmt.Sprintf("g%d-%d", g, i))
+				c.Debug("filtered")
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	if err := c.Close(); err != nil {
+		t.Errorf("Expect:nil, get:%v", err)
+	}
+	if err := c.Info("late"); err != ErrClosed {
+		t.Errorf("Expect:%v, get:%v", ErrClosed, err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 800 {
+		t.Fatalf("Expect:800 lines, get:%d", len(lines))
+	}
+
+	// 不同goroutine之间可以交错，同一goroutine内保持顺序
+	next := make(map[int]int)
+	for _, line := range lines {
+		var g, i int
+		if _, err := fmt.Sscanf(line, "6|g%d-%d", &g, &i); err != nil {
+			t.Fatalf("bad line %q", line)
+		}
+		if next[g] != i {
+			t.Errorf("g%d Expect:%d, get:%d", g, next[g], i)
+		}
+		next[g] = i + 1
+	}
+}
+
+func Test_channelWriterOutput(t *testing.T) {
+	r := NewRecorder()
+	r.now = (&fakeClock{t: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}).now
+	r.SetRateLimit(LOG_WARNING, 1)
+
+	var routed bytes.Buffer
+	sink := newTestFlog(&routed, LOG_LOCAL0|LOG_DEBUG, "test")
+	sink.SetFormatter(CompactFormatter{})
+	r.SetSeverityRoute(LOG_CRIT, sink)
+
+	var fired int
+	r.AddHook(HookFunc(func(Priority, string, time.Time) error {
+		fired++
+		return nil
+	}))
+
+	c := NewChannelWriter(r.Flog, 16)
+	c.Info("info")
+	c.Warning("warning")
+	c.Warning("limited")
+	c.Crit("routed")
+	c.Close()
+
+	// Close时还会写出被限流的条数
+	if e := r.Entries(); len(e) != 3 || e[0].Msg != "info" || e[1].Msg != "warning" || e[2].Msg != "suppressed 1 messages" {
+		t.Errorf("Expect: info, warning and summary recorded, get:%+v", e)
+	}
+	if s := routed.String(); s != "2|routed\n" {
+		t.Errorf("Expect:%q, get:%q", "2|routed\n", s)
+	}
+	s := r.Stats()
+	if s.Written[LOG_INFO] != 1 || s.Written[LOG_WARNING] != 2 || s.Dropped[LOG_WARNING] != 1 {
+		t.Errorf("Expect: written and dropped counted, get:%v %v", s.Written, s.Dropped)
+	}
+	if fired != 3 {
+		t.Errorf("Expect: hooks fired 3 times, get:%d", fired)
+	}
+}
+
+type nopWriter struct{}
+
+func (nopWriter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func Benchmark_parallelMutex(b *testing.B) {
+	l := newFlog(nopCloser{nopWriter{}}, LOG_LOCAL0|LOG_INFO, "bench")
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			l.Info("benchmark message")
+		}
+	})
+}
+
+func Benchmark_parallelChannel(b *testing.B) {
+	l := newFlog(nopCloser{nopWriter{}}, LOG_LOCAL0|LOG_INFO, "bench")
+	l.noclose = true
+	c := NewChannelWriter(l, 1024)
+	defer c.Close()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			c.Info("benchmark message")
+		}
+	})
+}
@@ -0,0 +1,105 @@
+package flog
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// 单次合并写入的上限，超过后立即写出
+const coalesceMaxBuffer = 64 * 1024
+
+// coalescer 把window时间内到达的多次写入合并成一次底层写入。
+// Write和Close必须在持有mu时调用，定时器回调自己获取mu
+type coalescer struct {
+	mu     sync.Locker
+	w      io.WriteCloser
+	window time.Duration
+	buf    []byte
+	timer  *time.Timer
+	err    error
+}
+
+func newCoalescer(mu sync.Locker, w io.WriteCloser, window time.Duration) *coalescer {
+	return &coalescer{mu: mu, w: w, window: window}
+}
+
+func (c *coalescer) Write(p []byte) (int, error) {
+	// 上一次异步写入的错误延迟到这里返回
+	if c.err != nil {
+		err := c.err
+		c.err = nil
+		return 0, err
+	}
+
+	c.buf = append(c.buf, p...)
+
+	if len(c.buf) >= coalesceMaxBuffer {
+		if err := c.flush(); err != nil {
+			c.err = nil
+			return 0, err
+		}
+		return len(p), nil
+	}
+
+	if c.timer == nil {
+		c.timer = time.AfterFunc(c.window, c.onTimer)
+	}
+
+	return len(p), nil
+}
+
+func (c *coalescer) onTimer() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.timer = nil
+	c.flush()
+}
+
+func (c *coalescer) flush() error {
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+
+	if len(c.buf) == 0 {
+		return nil
+	}
+
+	_, err := c.w.Write(c.buf)
+	c.buf = c.buf[:0]
+	if err != nil {
+		c.err = err
+	}
+	return err
+}
+
+func (c *coalescer) Close() error {
+	err := c.flush()
+	if err1 := c.w.Close(); err == nil {
+		err = err1
+	}
+	return err
+}
+
+// SetCoalesce 开启合并写入：window时间内的日志合并为一次写入，
+// 用于减少突发日志时的系统调用。window为0时不合并
+func (w *Flog) SetCoalesce(window time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if c, ok := w.w.(*coalescer); ok {
+		c.flush()
+		if window <= 0 {
+			w.w = c.w
+		} else {
+			c.window = window
+		}
+		return
+	}
+
+	if window > 0 {
+		w.w = newCoalescer(&w.mu, w.w, window)
+	}
+}
@@ -0,0 +1,81 @@
+package flog
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type countingWriter struct {
+	mu     sync.Mutex
+	writes int
+	data   strings.Builder
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.writes++
+	c.data.Write(p)
+	return len(p), nil
+}
+
+func (c *countingWriter) Close() error {
+	return nil
+}
+
+func (c *countingWriter) stats() (int, string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.writes, c.data.String()
+}
+
+func Test_coalesce(t *testing.T) {
+	cw := new(countingWriter)
+	l := newFlog(cw, LOG_LOCAL0|LOG_INFO, "test")
+	l.SetFormatter(CompactFormatter{})
+	l.SetCoalesce(20 * time.Millisecond)
+
+	for i := 0; i < 100; i++ {
+		l.Info("burst")
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	writes, data := cw.stats()
+	if writes != 1 {
+		t.Errorf("Expect:1 write, get:%d", writes)
+	}
+	if n := strings.Count(data, "6|burst\n"); n != 100 {
+		t.Errorf("Expect:100 lines, get:%d", n)
+	}
+}
+
+func benchmarkBurst(b *testing.B, window time.Duration) {
+	cw := new(countingWriter)
+	l := newFlog(cw, LOG_LOCAL0|LOG_INFO, "bench")
+	l.SetCoalesce(window)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < 100; j++ {
+			l.Info("burst message")
+		}
+	}
+	b.StopTimer()
+
+	l.SetCoalesce(0)
+	writes, _ := cw.stats()
+	b.ReportMetric(float64(writes)/float64(b.N), "writes/burst")
+}
+
+func Benchmark_burstDirect(b *testing.B) {
+	benchmarkBurst(b, 0)
+}
+
+func Benchmark_burstCoalesce(b *testing.B) {
+	benchmarkBurst(b, time.Millisecond)
+}
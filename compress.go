@@ -0,0 +1,155 @@
+package flog
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// WithCompress 轮转出的文件(app.log.1、按日期切换前的文件)在后台
+// 压缩为.gz后删除原文件，压缩不阻塞写日志。
+// 压缩先写入临时文件，完成后才改名，进程中途退出不会留下不完整的.gz
+func WithCompress(on bool) Option {
+	return func(w *Flog) {
+		w.opts.compress = on
+	}
+}
+
+// WithCompressIndexed 同WithCompress，压缩时使用CompressIndexed的格式并生成.gz.idx索引，
+// 之后可以用OpenIndexed从某个时间附近开始读取备份
+func WithCompressIndexed() Option {
+	return func(w *Flog) {
+		w.opts.compress = true
+		w.opts.compressIndexed = true
+	}
+}
+
+// compressJob 是一个压缩中的文件：index为0时是base本身，
+// 否则是base.index，轮转后移备份时index随之增加
+type compressJob struct {
+	base  string
+	index int
+}
+
+func (j *compressJob) path() string {
+	if j.index == 0 {
+		return j.base
+	}
+	return j.base + "." + strconv.Itoa(j.index)
+}
+
+// compress 在后台压缩base.index，调用时必须持有cmu
+func (fw *fileWriter) compress(base string, index int) {
+	j := &compressJob{base: base, index: index}
+
+	// 在改名前打开，之后的轮转改名不影响读取
+	in, err := os.Open(j.path())
+	if err != nil {
+		return
+	}
+
+	fw.jobs = append(fw.jobs, j)
+	fw.wg.Add(1)
+	go func() {
+		defer fw.wg.Done()
+		defer in.Close()
+
+		var idx []gzindexEntry
+		var tmp string
+		var err error
+		if fw.opts.compressIndexed {
+			tmp, idx, err = gzipIndexedToTemp(in, j.base)
+		} else {
+			tmp, err = gzipToTemp(in, j.base)
+		}
+
+		fw.cmu.Lock()
+		defer fw.cmu.Unlock()
+
+		for i, v := range fw.jobs {
+			if v == j {
+				fw.jobs = append(fw.jobs[:i], fw.jobs[i+1:]...)
+				break
+			}
+		}
+
+		if err != nil {
+			return
+		}
+
+		// 压缩期间被MaxBackups删除的不再保留
+		if n := fw.opts.maxBackups; n > 0 && j.index > n {
+			os.Remove(tmp)
+			return
+		}
+		if idx != nil {
+			if err := writeGzindex(j.path()+".gz.idx", idx); err != nil {
+				os.Remove(tmp)
+				return
+			}
+		}
+		if err := os.Rename(tmp, j.path()+".gz"); err != nil {
+			os.Remove(tmp)
+			os.Remove(j.path() + ".gz.idx")
+			return
+		}
+		os.Remove(j.path())
+		fw.retain()
+	}()
+}
+
+// gzipToTemp 把in压缩到base所在目录的临时文件，返回临时文件名
+func gzipToTemp(in io.Reader, base string) (string, error) {
+	out, err := os.CreateTemp(filepath.Dir(base), filepath.Base(base)+".*.gz.tmp")
+	if err != nil {
+		return "", err
+	}
+
+	zw := gzip.NewWriter(out)
+	_, err = io.Copy(zw, in)
+	if err1 := zw.Close(); err == nil {
+		err = err1
+	}
+	if err1 := out.Close(); err == nil {
+		err = err1
+	}
+	if err != nil {
+		os.Remove(out.Name())
+		return "", err
+	}
+	return out.Name(), nil
+}
+
+// gzipIndexedToTemp 同gzipToTemp，使用CompressIndexed的格式，同时返回索引
+func gzipIndexedToTemp(in *os.File, base string) (string, []gzindexEntry, error) {
+	ref := time.Now()
+	if fi, err := in.Stat(); err == nil {
+		ref = fi.ModTime()
+	}
+
+	out, err := os.CreateTemp(filepath.Dir(base), filepath.Base(base)+".*.gz.tmp")
+	if err != nil {
+		return "", nil, err
+	}
+
+	idx, err := writeIndexedGzip(out, in, ref)
+	if err1 := out.Close(); err == nil {
+		err = err1
+	}
+	if err != nil {
+		os.Remove(out.Name())
+		return "", nil, err
+	}
+	if idx == nil {
+		idx = []gzindexEntry{}
+	}
+	return out.Name(), idx, nil
+}
+
+// waitCompress 等待后台压缩完成，调用时不能持有cmu
+func (fw *fileWriter) waitCompress() {
+	fw.wg.Wait()
+}
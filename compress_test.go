@@ -0,0 +1,90 @@
+package flog
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func readGzip(t *testing.T, name string) string {
+	f, err := os.Open(name)
+	if err != nil {
+		t.Errorf("Expect:nil, get:%v", err)
+		return ""
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Errorf("Expect:nil, get:%v", err)
+		return ""
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Errorf("Expect:nil, get:%v", err)
+	}
+	return string(data)
+}
+
+func Test_compress(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.log")
+
+	l, err := File(file, LOG_LOCAL0|LOG_INFO, "test", WithMaxSize(20), WithMaxBackups(2), WithCompress(true))
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	l.SetFormatter(CompactFormatter{})
+
+	for i := 0; i < 7; i++ {
+		l.Info("line-" + strconv.Itoa(i))
+	}
+	l.Close()
+
+	if s := readFile(t, file); s != "6|line-6\n" {
+		t.Errorf("Expect:%q, get:%q", "6|line-6\n", s)
+	}
+	if s := readGzip(t, file+".1.gz"); s != "6|line-4\n6|line-5\n" {
+		t.Errorf("Expect:%q, get:%q", "6|line-4\n6|line-5\n", s)
+	}
+	if s := readGzip(t, file+".2.gz"); s != "6|line-2\n6|line-3\n" {
+		t.Errorf("Expect:%q, get:%q", "6|line-2\n6|line-3\n", s)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	if len(files) != 3 {
+		t.Errorf("Expect: app.log and 2 .gz backups, get:%v", files)
+	}
+}
+
+func Test_compressDaily(t *testing.T) {
+	dir := t.TempDir()
+
+	l, err := File(filepath.Join(dir, "app-%Y-%m-%d.log"), LOG_LOCAL0|LOG_INFO, "test", WithLocation(time.UTC), WithCompress(true))
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	l.SetFormatter(CompactFormatter{})
+
+	c := &fakeClock{t: time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)}
+	l.now = c.now
+
+	l.Info("day1")
+	c.add(24 * time.Hour)
+	l.Info("day2")
+	l.Close()
+
+	if s := readGzip(t, filepath.Join(dir, "app-2020-01-01.log.gz")); s != "6|day1\n" {
+		t.Errorf("Expect:%q, get:%q", "6|day1\n", s)
+	}
+	if exists(filepath.Join(dir, "app-2020-01-01.log")) {
+		t.Errorf("Expect: original removed")
+	}
+	if s := readFile(t, filepath.Join(dir, "app-2020-01-02.log")); s != "6|day2\n" {
+		t.Errorf("Expect:%q, get:%q", "6|day2\n", s)
+	}
+}
@@ -0,0 +1,224 @@
+package flog
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Config 是可以在运行时通过Reconfigure切换的配置
+type Config struct {
+	Target   string // 同New的filename，可以带参数，不支持本机syslog和journald，格式以Format为准
+	Priority string // 同New的priority，如"local0:debug"
+	Tag      string
+	Format   Format
+
+	MaxSize      int64 // 见WithMaxSize
+	MaxBackups   int   // 见WithMaxBackups
+	MaxTotalSize int64 // 见WithMaxTotalSize
+	Compress     bool  // 见WithCompress
+}
+
+func (c *Config) options() []Option {
+	return []Option{
+		WithFormat(c.Format),
+		WithMaxSize(c.MaxSize),
+		WithMaxBackups(c.MaxBackups),
+		WithMaxTotalSize(c.MaxTotalSize),
+		WithCompress(c.Compress),
+	}
+}
+
+// sameOutput 报告两个配置是否使用同一个输出
+func (c *Config) sameOutput(o *Config) bool {
+	return c.Target == o.Target && c.MaxSize == o.MaxSize && c.MaxBackups == o.MaxBackups &&
+		c.MaxTotalSize == o.MaxTotalSize && c.Compress == o.Compress
+}
+
+// checkConfig 解析cfg.Target，返回生效的priority和tag
+func checkConfig(cfg *Config) (*dsn, Priority, string, error) {
+	d, err := parseDSN(cfg.Target)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	if d.scheme == "syslog" || d.scheme == "journald" {
+		return nil, 0, "", fmt.Errorf("flog: %w: %s in Config", ErrNotSupported, cfg.Target)
+	}
+
+	level, tag := cfg.Priority, cfg.Tag
+	if d.level != "" {
+		level = d.level
+	}
+	if d.tag != "" {
+		tag = d.tag
+	}
+
+	p, err := log_level(level)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	return d, p, tag, nil
+}
+
+// NewFromConfig 按cfg创建日志，opts在cfg之后应用
+func NewFromConfig(cfg Config, opts ...Option) (*Flog, error) {
+	if _, _, _, err := checkConfig(&cfg); err != nil {
+		return nil, err
+	}
+
+	w, err := New(cfg.Target, cfg.Priority, cfg.Tag, append(cfg.options(), opts...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	l := w.(*Flog)
+	l.mu.Lock()
+	l.cfg = &cfg
+	l.mu.Unlock()
+	return l, nil
+}
+
+// Reconfigure 在运行时切换级别、tag、格式和输出，可以在写日志的同时调用。
+// 输出或轮转参数变化时先打开新的输出，打开失败时返回错误且配置不变；
+// 成功后之前的日志写入旧的输出，然后关闭旧的输出
+func (w *Flog) Reconfigure(cfg Config) error {
+	d, p, tag, err := checkConfig(&cfg)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return ErrClosed
+	}
+	var t *Flog
+	if w.cfg == nil || !w.cfg.sameOutput(&cfg) {
+		// 新文件使用自己的options，旧文件的后台压缩可能还在读取旧的
+		t = &Flog{opts: w.opts}
+	}
+	w.mu.Unlock()
+
+	// 在加锁之前打开新的输出，连接网络时不阻塞写日志
+	var out io.WriteCloser
+	var fw *fileWriter
+	var noclose bool
+	if t != nil {
+		t.apply(d.opts)
+		t.apply(cfg.options())
+
+		out, fw, noclose, err = openOutput(d, &t.opts, func() time.Time { return w.now() })
+		if err != nil {
+			return err
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		if out != nil && !noclose {
+			out.Close()
+		}
+		return ErrClosed
+	}
+
+	if t != nil {
+		w.switchOutput(t, out, fw, noclose)
+	}
+
+	w.priority = p
+	w.filter = p & severityMask
+	w.tag = tag
+	w.SetFormat(cfg.Format)
+	w.cfg = &cfg
+	return nil
+}
+
+// switchOutput 用已经打开的out替换当前的输出，t带有新输出的options，调用时必须持有mu
+func (w *Flog) switchOutput(t *Flog, out io.WriteCloser, fw *fileWriter, noclose bool) {
+	if tf := t.timeFmt.Load(); tf != nil {
+		w.timeFmt.Store(tf)
+	}
+
+	// 已经缓存或在队列中的日志先写入旧的输出
+	c, _ := w.w.(*coalescer)
+	if c != nil {
+		c.flush()
+	}
+	if w.async != nil {
+		done := make(chan error, 1)
+		if err := w.async.put(asyncRecord{flush: true, done: done}); err == nil {
+			<-done
+		}
+	}
+
+	unlock := w.lockFile()
+	var old io.WriteCloser
+	switch {
+	case w.async != nil:
+		old = w.async.next
+		w.async.next, w.async.file = out, fw
+	case c != nil:
+		old = c.w
+		c.w = out
+	default:
+		old = w.w
+		w.w = out
+	}
+	oldNoclose := w.noclose
+	w.file, w.noclose = fw, noclose
+	unlock()
+
+	// 旧文件的后台压缩还在读取它的options，这里只更新Flog自己使用的部分
+	w.opts.datasync = t.opts.datasync
+	w.opts.buffer, w.opts.flushEvery = t.opts.buffer, t.opts.flushEvery
+	w.opts.flushOnErr = t.opts.flushOnErr
+
+	// 新文件需要的后台goroutine还没有启动时启动，已有的不重复启动
+	if fw != nil {
+		if t.opts.datasync > 0 && !w.syncing {
+			w.goDatasync(t.opts.datasync)
+		}
+		if t.opts.buffer > 0 && t.opts.flushEvery > 0 && !w.flushing {
+			w.goFlush(t.opts.flushEvery)
+		}
+	}
+
+	if oldNoclose {
+		if f, ok := old.(interface{ flush() error }); ok {
+			f.flush()
+		}
+		return
+	}
+	old.Close()
+}
+
+// openOutput 打开d的输出(本机syslog和journald除外)
+func openOutput(d *dsn, o *options, now func() time.Time) (out io.WriteCloser, fw *fileWriter, noclose bool, err error) {
+	switch d.scheme {
+	case "stderr":
+		return os.Stderr, nil, true, nil
+	case "stdout":
+		return os.Stdout, nil, true, nil
+	case "file":
+	default:
+		nw := newNetWriter(d.scheme, d.addr, o.net)
+		if err := nw.connect(); err != nil {
+			return nil, nil, false, err
+		}
+		return nw, nil, false, nil
+	}
+
+	flag := os.O_WRONLY | os.O_APPEND | os.O_CREATE
+	if o.datasync <= 0 && o.buffer <= 0 {
+		flag |= os.O_SYNC
+	}
+
+	fw, err = openFileWriter(d.addr, flag, o, now)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	return fw, fw, false, nil
+}
@@ -0,0 +1,130 @@
+package flog
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_reconfigure(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.log")
+	b := filepath.Join(dir, "b.log")
+
+	l, err := NewFromConfig(Config{Target: a, Priority: "local0:info", Tag: "app", Format: FormatCompact})
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	defer l.Close()
+
+	l.Debug("filtered")
+	l.Info("to a")
+
+	// 只修改级别，输出不变
+	if err := l.Reconfigure(Config{Target: a, Priority: "local0:debug", Tag: "app", Format: FormatCompact}); err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	fw := l.file
+	l.Debug("debug to a")
+
+	// 打开失败时配置不变
+	bad := Config{Target: filepath.Join(dir, "missing", "c.log"), Priority: "local0:err", Tag: "app"}
+	if err := l.Reconfigure(bad); err == nil {
+		t.Errorf("Expect: error")
+	}
+	if _, err := NewFromConfig(Config{Target: "<syslog>"}); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("Expect:%v, get:%v", ErrNotSupported, err)
+	}
+	if err := l.Reconfigure(Config{Target: b, Priority: "nope"}); err == nil {
+		t.Errorf("Expect: error")
+	}
+	if l.file != fw {
+		t.Errorf("Expect: output unchanged")
+	}
+
+	if err := l.Reconfigure(Config{Target: b, Priority: "local0:warning", Tag: "app", Format: FormatJSON}); err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	l.Info("filtered")
+	l.Warning("to b")
+	l.Close()
+
+	if s := readFile(t, a); s != "6|to a\n7|debug to a\n" {
+		t.Errorf("Expect:%q, get:%q", "6|to a\n7|debug to a\n", s)
+	}
+	if s := readFile(t, b); !strings.HasPrefix(s, "{") || !strings.Contains(s, `"msg":"to b"`) || strings.Contains(s, "filtered") {
+		t.Errorf("Expect: json line to b, get:%q", s)
+	}
+}
+
+func Test_reconfigureConcurrent(t *testing.T) {
+	dir := t.TempDir()
+	names := []string{filepath.Join(dir, "a.log"), filepath.Join(dir, "b.log")}
+
+	l, err := NewFromConfig(Config{Target: names[0], Priority: "local0:info", Tag: "app", Format: FormatCompact}, WithAsync(16))
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				l.Info("x")
+			}
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		if err := l.Reconfigure(Config{Target: names[i%2], Priority: "local0:info", Tag: "app", Format: FormatCompact}); err != nil {
+			t.Errorf("Expect:nil, get:%v", err)
+		}
+	}
+	wg.Wait()
+	l.Close()
+
+	n := 0
+	for _, name := range names {
+		n += strings.Count(readFile(t, name), "\n")
+	}
+	if n != 800 {
+		t.Errorf("Expect:800, get:%d", n)
+	}
+}
+
+func Test_reconfigureBuffered(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.log")
+	b := filepath.Join(dir, "b.log")
+
+	l, err := NewFromConfig(Config{Target: a, Priority: "local0:info", Tag: "app", Format: FormatCompact}, WithDatasync(time.Hour))
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	defer l.Close()
+
+	// 新输出带缓存，需要启动定时写出
+	if err := l.Reconfigure(Config{Target: "file://" + b + "?buffer=4KB&flush=10ms", Priority: "local0:info", Tag: "app", Format: FormatCompact}); err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	l.Info("buffered")
+
+	deadline := time.Now().Add(2 * time.Second)
+	for readFile(t, b) != "6|buffered\n" && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if s := readFile(t, b); s != "6|buffered\n" {
+		t.Errorf("Expect: flushed in background, get:%q", s)
+	}
+
+	l.mu.Lock()
+	buffer, flushing := l.opts.buffer, l.flushing
+	l.mu.Unlock()
+	if buffer != 4096 || !flushing {
+		t.Errorf("Expect: options of new output, get:%d %v", buffer, flushing)
+	}
+}
@@ -0,0 +1,60 @@
+package flog
+
+import (
+	"os"
+)
+
+// 各级别的ANSI颜色
+var severityColors = [...]string{
+	"\x1b[1;31m", "\x1b[1;31m", "\x1b[1;31m", "\x1b[31m",
+	"\x1b[33m", "\x1b[36m", "\x1b[32m", "\x1b[90m",
+}
+
+const colorReset = "\x1b[0m"
+
+// ConsoleFormatter 用于开发时在终端中查看，输出短时间戳、带颜色的级别和对齐的tag：
+// "15:04:05.000 WARNING app      msg k=v"，不含<pri>和pid
+type ConsoleFormatter struct {
+	NoColor  bool // 不输出颜色
+	TagWidth int  // tag的对齐宽度，0时为8
+}
+
+func (f ConsoleFormatter) Format(b []byte, r *Record) []byte {
+	b = r.Time.AppendFormat(b, r.timeLayout("15:04:05.000"))
+	b = append(b, ' ')
+
+	sev := r.Priority & severityMask
+	if !f.NoColor {
+		b = append(b, severityColors[sev]...)
+	}
+	b = append(b, severityLabels[sev]...)
+	if !f.NoColor {
+		b = append(b, colorReset...)
+	}
+	b = appendPad(b, len(severityLabels[sev]), len("WARNING"))
+
+	width := f.TagWidth
+	if width <= 0 {
+		width = 8
+	}
+	b = append(b, ' ')
+	b = append(b, r.Tag...)
+	b = appendPad(b, len(r.Tag), width)
+
+	return appendMsg(b, " ", r.Msg, r.Fields)
+}
+
+func appendPad(b []byte, n, width int) []byte {
+	for ; n < width; n++ {
+		b = append(b, ' ')
+	}
+	return b
+}
+
+// consoleFormatter 在f是终端时返回ConsoleFormatter，设置了NO_COLOR环境变量时不输出颜色
+func consoleFormatter(f *os.File) (Formatter, bool) {
+	if !isTerminal(f) {
+		return nil, false
+	}
+	return ConsoleFormatter{NoColor: os.Getenv("NO_COLOR") != ""}, true
+}
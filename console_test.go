@@ -0,0 +1,53 @@
+package flog
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+)
+
+func Test_consoleFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestFlog(&buf, LOG_LOCAL0|LOG_DEBUG, "app")
+	l.now = (&fakeClock{t: time.Date(2020, 3, 4, 5, 6, 7, 123e6, time.UTC)}).now
+
+	l.SetFormat(FormatConsole)
+	l.Err("disk failed")
+	l.SetFormatter(ConsoleFormatter{NoColor: true, TagWidth: 4})
+	l.With("k", "v").Info("ok")
+
+	expect := "05:06:07.123 \x1b[31mERR\x1b[0m     app      disk failed\n" +
+		"05:06:07.123 INFO    app  ok k=v\n"
+	if buf.String() != expect {
+		t.Errorf("Expect:%q, get:%q", expect, buf.String())
+	}
+}
+
+func Test_consoleAuto(t *testing.T) {
+	old := isTerminal
+	defer func() { isTerminal = old }()
+
+	for _, tty := range []bool{true, false} {
+		isTerminal = func(*os.File) bool { return tty }
+
+		w, err := New("<stderr>", "", "test")
+		if err != nil {
+			t.Fatalf("Expect:nil, get:%v", err)
+		}
+		expect := "human"
+		if tty {
+			expect = "console"
+		}
+		if f := formatterName(w.(*Flog).getFormatter()); f != expect {
+			t.Errorf("tty=%v Expect:%s, get:%s", tty, expect, f)
+		}
+	}
+
+	// 选项优先于自动选择
+	isTerminal = func(*os.File) bool { return true }
+	w, _ := New("<stdout>", "", "test", WithFormat(FormatJSON))
+	if f := formatterName(w.(*Flog).getFormatter()); f != "json" {
+		t.Errorf("Expect:json, get:%s", f)
+	}
+}
@@ -0,0 +1,66 @@
+package flog
+
+import (
+	"context"
+	"time"
+)
+
+type ctxKey struct{}
+
+// ContextWith 返回带有keyvals键值对的ctx，如 ContextWith(ctx, "request_id", id)。
+// 通过Ctx记录的日志会附加这些字段，多次调用的字段会累加
+func ContextWith(ctx context.Context, keyvals ...interface{}) context.Context {
+	old, _ := ctx.Value(ctxKey{}).([]Field)
+	fields := make([]Field, 0, len(old)+(len(keyvals)+1)/2)
+	fields = append(fields, old...)
+	fields = appendKeyvals(fields, keyvals)
+	return context.WithValue(ctx, ctxKey{}, fields)
+}
+
+// ContextWithNewID 返回带有以key为名的新生成ID的ctx，见NewID
+func (w *Flog) ContextWithNewID(ctx context.Context, key string) context.Context {
+	return ContextWith(ctx, key, w.NewID())
+}
+
+// AddContextExtractor 追加一个从ctx中取出字段的函数，如取出OpenTelemetry的
+// trace_id和span_id，不必让本包依赖OpenTelemetry
+func (w *Flog) AddContextExtractor(f func(ctx context.Context) []Field) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var fs []func(context.Context) []Field
+	if old := w.extractors.Load(); old != nil {
+		fs = append(fs, *old...)
+	}
+	fs = append(fs, f)
+	w.extractors.Store(&fs)
+}
+
+// Ctx 返回一个日志，其消息带有ctx中的字段：ContextWith添加的字段、
+// AddContextExtractor取出的字段、剩余时间deadline，ctx结束时还有ctx_err
+func (w *Flog) Ctx(ctx context.Context) *Entry {
+	return (&Entry{l: w}).Ctx(ctx)
+}
+
+func (e *Entry) Ctx(ctx context.Context) *Entry {
+	return e.with(e.l.contextFields(ctx)...)
+}
+
+func (w *Flog) contextFields(ctx context.Context) []Field {
+	fields, _ := ctx.Value(ctxKey{}).([]Field)
+	fields = fields[:len(fields):len(fields)]
+
+	if fs := w.extractors.Load(); fs != nil {
+		for _, f := range *fs {
+			fields = append(fields, f(ctx)...)
+		}
+	}
+
+	if dl, ok := ctx.Deadline(); ok {
+		fields = append(fields, Field{"deadline", dl.Sub(w.now()).Round(time.Millisecond)})
+	}
+	if err := ctx.Err(); err != nil {
+		fields = append(fields, Field{"ctx_err", err.Error()})
+	}
+	return fields
+}
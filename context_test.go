@@ -0,0 +1,51 @@
+package flog
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+type traceKey struct{}
+
+func Test_ctx(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestFlog(&buf, LOG_LOCAL0|LOG_INFO, "test")
+	l.SetFormatter(CompactFormatter{})
+	now := time.Now()
+	l.now = (&fakeClock{t: now}).now
+	l.SetIDGenerator(func() string { return "id1" })
+
+	// 模拟从OpenTelemetry的span中取出trace id
+	l.AddContextExtractor(func(ctx context.Context) []Field {
+		if id, ok := ctx.Value(traceKey{}).(string); ok {
+			return []Field{{"trace_id", id}}
+		}
+		return nil
+	})
+
+	ctx := l.ContextWithNewID(context.Background(), "request_id")
+	ctx = ContextWith(ctx, "user", "bob")
+	l.Ctx(ctx).Info("start")
+
+	ctx = context.WithValue(ctx, traceKey{}, "abc")
+	l.With("k", 1).Ctx(ctx).Infof("traced %d", 2)
+
+	dctx, cancel := context.WithDeadline(context.Background(), now.Add(1500*time.Millisecond))
+	l.Ctx(dctx).Info("deadline")
+	cancel()
+	l.Ctx(dctx).Info("canceled")
+
+	slog.New(NewSlogHandler(l, nil)).InfoContext(ctx, "slog", "a", 1)
+
+	expect := "6|start request_id=id1 user=bob\n" +
+		"6|traced 2 k=1 request_id=id1 user=bob trace_id=abc\n" +
+		"6|deadline deadline=1.5s\n" +
+		"6|canceled deadline=1.5s ctx_err=\"context canceled\"\n" +
+		"6|slog request_id=id1 user=bob trace_id=abc a=1\n"
+	if buf.String() != expect {
+		t.Errorf("Expect:%q, get:%q", expect, buf.String())
+	}
+}
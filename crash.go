@@ -0,0 +1,85 @@
+package flog
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+)
+
+// ring 是无锁的定长环形缓存，只保留最近写入的len(slots)条
+type ring struct {
+	slots []atomic.Pointer[[]byte]
+	pos   atomic.Uint64
+}
+
+func newRing(n int) *ring {
+	return &ring{slots: make([]atomic.Pointer[[]byte], n)}
+}
+
+func (r *ring) put(b []byte) {
+	i := r.pos.Add(1) - 1
+	r.slots[i%uint64(len(r.slots))].Store(&b)
+}
+
+func (r *ring) snapshot() [][]byte {
+	end := r.pos.Load()
+	start := uint64(0)
+	if end > uint64(len(r.slots)) {
+		start = end - uint64(len(r.slots))
+	}
+
+	out := make([][]byte, 0, end-start)
+	for i := start; i < end; i++ {
+		if b := r.slots[i%uint64(len(r.slots))].Load(); b != nil {
+			out = append(out, *b)
+		}
+	}
+	return out
+}
+
+// WithCrashRing 在内存中保留最近n条日志(包括被级别过滤掉的)，
+// 程序panic时由Recover写入crashFile，用于保留崩溃前的调试信息
+func WithCrashRing(n int, crashFile string) Option {
+	return func(w *Flog) {
+		if n > 0 {
+			w.ring = newRing(n)
+			w.crashFile = crashFile
+		}
+	}
+}
+
+// DumpCrashRing 把环形缓存中的日志写入crashFile
+func (w *Flog) DumpCrashRing() error {
+	if w.ring == nil {
+		return nil
+	}
+
+	f, err := os.OpenFile(w.crashFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+
+	for _, b := range w.ring.snapshot() {
+		if _, err = f.Write(b); err != nil {
+			break
+		}
+	}
+
+	if err1 := f.Close(); err == nil {
+		err = err1
+	}
+	return err
+}
+
+// Recover 用于 defer l.Recover()：捕获到panic时记录一条Crit日志，
+// 写出崩溃缓存，然后继续panic
+func (w *Flog) Recover() {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	w.Crit(fmt.Sprintf("panic: %v", r))
+	w.DumpCrashRing()
+	panic(r)
+}
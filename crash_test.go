@@ -0,0 +1,50 @@
+package flog
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func Test_crashRing(t *testing.T) {
+	var buf bytes.Buffer
+	crash := filepath.Join(t.TempDir(), "crash.log")
+
+	l := newTestFlog(&buf, LOG_LOCAL0|LOG_INFO, "test")
+	l.apply([]Option{WithCrashRing(3, crash)})
+	l.SetFormatter(CompactFormatter{})
+
+	for i := 0; i < 5; i++ {
+		l.Debug("step" + strconv.Itoa(i))
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("Expect: re-panic boom, get:%v", r)
+			}
+		}()
+		defer l.Recover()
+		panic("boom")
+	}()
+
+	if strings.Contains(buf.String(), "step") {
+		t.Errorf("Expect: debug filtered, get:%q", buf.String())
+	}
+	if buf.String() != "2|panic: boom\n" {
+		t.Errorf("Expect:%q, get:%q", "2|panic: boom\n", buf.String())
+	}
+
+	data, err := os.ReadFile(crash)
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+
+	expect := "7|step3\n7|step4\n2|panic: boom\n"
+	if string(data) != expect {
+		t.Errorf("Expect:%q, get:%q", expect, data)
+	}
+}
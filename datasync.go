@@ -0,0 +1,35 @@
+package flog
+
+import (
+	"time"
+)
+
+func (w *Flog) goDatasync(interval time.Duration) {
+	w.syncing = true
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-t.C:
+			case <-w.done:
+				return
+			}
+			w.syncFile()
+		}
+	}()
+}
+
+func (w *Flog) syncFile() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.dirty || w.closed || w.file == nil {
+		return nil
+	}
+	w.dirty = false
+
+	defer w.lockFile()()
+	return w.file.datasync()
+}
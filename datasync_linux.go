@@ -0,0 +1,10 @@
+package flog
+
+import (
+	"os"
+	"syscall"
+)
+
+func datasync(f *os.File) error {
+	return syscall.Fdatasync(int(f.Fd()))
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package flog
+
+import (
+	"os"
+)
+
+func datasync(f *os.File) error {
+	return f.Sync()
+}
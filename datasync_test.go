@@ -0,0 +1,76 @@
+package flog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_datasync(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "datasync.log")
+
+	l, err := File(file, LOG_LOCAL0|LOG_INFO, "test", WithDatasync(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	defer l.Close()
+
+	l.Info("synced")
+	time.Sleep(50 * time.Millisecond)
+
+	l.mu.Lock()
+	dirty := l.dirty
+	l.mu.Unlock()
+	if dirty {
+		t.Errorf("Expect: synced, get: dirty")
+	}
+
+	data, _ := os.ReadFile(file)
+	if !strings.Contains(string(data), "synced") {
+		t.Errorf("Expect: synced, get:%q", data)
+	}
+}
+
+func Test_datasyncNotFile(t *testing.T) {
+	var buf closeRecorder
+	l, err := NewFromWriter(&buf, LOG_LOCAL0|LOG_INFO, LOG_INFO, "test", WithDatasync(time.Millisecond))
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+
+	l.Info("not a file")
+	if err := l.syncFile(); err != nil {
+		t.Errorf("Expect:nil, get:%v", err)
+	}
+	if err := l.Close(); err != nil {
+		t.Errorf("Expect:nil, get:%v", err)
+	}
+	if !buf.closed {
+		t.Errorf("Expect: writer closed")
+	}
+}
+
+func benchmarkFile(b *testing.B, opts ...Option) {
+	file := filepath.Join(b.TempDir(), "bench.log")
+
+	l, err := File(file, LOG_LOCAL0|LOG_INFO, "bench", opts...)
+	if err != nil {
+		b.Fatalf("Expect:nil, get:%v", err)
+	}
+	defer l.Close()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.Info("benchmark message")
+	}
+}
+
+func Benchmark_fileOSync(b *testing.B) {
+	benchmarkFile(b)
+}
+
+func Benchmark_fileDatasync(b *testing.B) {
+	benchmarkFile(b, WithDatasync(100*time.Millisecond))
+}
@@ -0,0 +1,33 @@
+package flog
+
+import (
+	"strconv"
+	"time"
+)
+
+// SetDeltaPrefix 开启后每行前加上距上一行的时间，如 "+0.123s "，
+// 第一行为 "+0.000s "。用于阅读日志时快速发现耗时
+func (w *Flog) SetDeltaPrefix(on bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.delta = on
+	w.last = time.Time{}
+}
+
+// appendDelta 返回加了前缀的行，调用时必须持有mu
+func (w *Flog) appendDelta(line []byte) []byte {
+	now := w.now()
+
+	var d time.Duration
+	if !w.last.IsZero() {
+		d = now.Sub(w.last)
+	}
+	w.last = now
+
+	b := make([]byte, 0, len(line)+12)
+	b = append(b, '+')
+	b = strconv.AppendFloat(b, d.Seconds(), 'f', 3, 64)
+	b = append(b, "s "...)
+	return append(b, line...)
+}
@@ -0,0 +1,33 @@
+package flog
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_deltaPrefix(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestFlog(&buf, LOG_LOCAL0|LOG_INFO, "test")
+	l.SetFormatter(CompactFormatter{})
+	l.SetDeltaPrefix(true)
+
+	l.Info("first")
+	time.Sleep(100 * time.Millisecond)
+	l.Info("second")
+
+	lines := strings.Split(buf.String(), "\n")
+	if lines[0] != "+0.000s 6|first" {
+		t.Errorf("Expect:%q, get:%q", "+0.000s 6|first", lines[0])
+	}
+
+	var d float64
+	if _, err := fmt.Sscanf(lines[1], "+%fs 6|second", &d); err != nil {
+		t.Fatalf("bad line %q", lines[1])
+	}
+	if d < 0.09 || d > 0.5 {
+		t.Errorf("Expect:~0.100, get:%.3f", d)
+	}
+}
@@ -0,0 +1,130 @@
+package flog
+
+import (
+	"sort"
+	"time"
+)
+
+// Entry 是带有附加字段的日志，与创建它的Flog共用输出和锁
+type Entry struct {
+	l      *Flog
+	fields []Field
+	sd     []SDElement
+	named  *Named
+}
+
+func (e *Entry) with(fields ...Field) *Entry {
+	n := &Entry{l: e.l, sd: e.sd, named: e.named}
+	n.fields = make([]Field, 0, len(e.fields)+len(fields))
+	n.fields = append(n.fields, e.fields...)
+	n.fields = append(n.fields, fields...)
+	return n
+}
+
+func (e *Entry) log(p Priority, m string) (int, error) {
+	return e.l.logRecord(&Record{Priority: p, Msg: m, Fields: e.fields, SD: e.sd, named: e.named})
+}
+
+// 奇数个参数时最后一个key的值
+const missingValue = "(MISSING)"
+
+// With 返回一个日志，其消息带有keyvals给出的键值对，如
+// With("request_id", id, "user", u)。与原日志共用输出和锁，
+// 多次With的字段会累加。最后一个key没有值时值为 (MISSING)
+func (w *Flog) With(keyvals ...interface{}) *Entry {
+	return (&Entry{l: w}).With(keyvals...)
+}
+
+func (e *Entry) With(keyvals ...interface{}) *Entry {
+	return e.with(appendKeyvals(nil, keyvals)...)
+}
+
+// appendKeyvals 把With形式的键值对追加到fields中
+func appendKeyvals(fields []Field, keyvals []interface{}) []Field {
+	for i := 0; i < len(keyvals); i += 2 {
+		f := Field{Key: fieldString(keyvals[i]), Value: missingValue}
+		if i+1 < len(keyvals) {
+			f.Value = keyvals[i+1]
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// WithFields 同With，fields按key排序后附加
+func (w *Flog) WithFields(fields map[string]interface{}) *Entry {
+	return (&Entry{l: w}).WithFields(fields)
+}
+
+func (e *Entry) WithFields(fields map[string]interface{}) *Entry {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fs := make([]Field, 0, len(keys))
+	for _, k := range keys {
+		fs = append(fs, Field{k, fields[k]})
+	}
+	return e.with(fs...)
+}
+
+// WithTTL 返回一个日志，其消息带有ttl字段(秒)，供下游存储决定保留时间，
+// 0表示永久保留。日志本身并不处理ttl
+func (w *Flog) WithTTL(d time.Duration) *Entry {
+	return (&Entry{l: w}).WithTTL(d)
+}
+
+func (e *Entry) WithTTL(d time.Duration) *Entry {
+	return e.with(Field{"ttl", int64(d / time.Second)})
+}
+
+// Close 不会关闭所属的Flog
+func (e *Entry) Close() error {
+	return nil
+}
+
+func (e *Entry) Write(b []byte) (int, error) {
+	return e.log(e.l.getPriority(), string(b))
+}
+
+func (e *Entry) Emerg(m string) (err error) {
+	_, err = e.log(LOG_EMERG, m)
+	return err
+}
+
+func (e *Entry) Alert(m string) (err error) {
+	_, err = e.log(LOG_ALERT, m)
+	return err
+}
+
+func (e *Entry) Crit(m string) (err error) {
+	_, err = e.log(LOG_CRIT, m)
+	return err
+}
+
+func (e *Entry) Err(m string) (err error) {
+	_, err = e.log(LOG_ERR, m)
+	return err
+}
+
+func (e *Entry) Warning(m string) (err error) {
+	_, err = e.log(LOG_WARNING, m)
+	return err
+}
+
+func (e *Entry) Notice(m string) (err error) {
+	_, err = e.log(LOG_NOTICE, m)
+	return err
+}
+
+func (e *Entry) Info(m string) (err error) {
+	_, err = e.log(LOG_INFO, m)
+	return err
+}
+
+func (e *Entry) Debug(m string) (err error) {
+	_, err = e.log(LOG_DEBUG, m)
+	return err
+}
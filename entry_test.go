@@ -0,0 +1,101 @@
+package flog
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_withTTL(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestFlog(&buf, LOG_LOCAL0|LOG_DEBUG, "test")
+	l.SetFormatter(CompactFormatter{})
+
+	var _ Writer = l.WithTTL(0)
+
+	l.WithTTL(time.Hour).Debug("verbose")
+	l.WithTTL(0).Notice("audit")
+	l.Info("plain")
+
+	expect := "7|verbose ttl=3600\n5|audit ttl=0\n6|plain\n"
+	if buf.String() != expect {
+		t.Errorf("Expect:%q, get:%q", expect, buf.String())
+	}
+}
+
+func Test_with(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestFlog(&buf, LOG_LOCAL0|LOG_DEBUG, "test")
+	l.SetFormatter(CompactFormatter{})
+
+	child := l.With("request_id", "abc", "latency", 12)
+	child.With("user", "bob", "dangling").Info("done")
+	child.Info("child")
+	l.Info("parent")
+
+	expect := "6|done request_id=abc latency=12 user=bob dangling=(MISSING)\n" +
+		"6|child request_id=abc latency=12\n" +
+		"6|parent\n"
+	if buf.String() != expect {
+		t.Errorf("Expect:%q, get:%q", expect, buf.String())
+	}
+
+	buf.Reset()
+	l.SetFormatter(NewJSONFormatter())
+	l.With("user", "bob").Info("json")
+	if s := buf.String(); !strings.Contains(s, `"msg":"json","user":"bob"}`) {
+		t.Errorf("Expect: field merged into object, get:%s", s)
+	}
+}
+
+func Test_withConcurrent(t *testing.T) {
+	var buf syncBuffer
+	l := newFlog(nopCloser{&buf}, LOG_LOCAL0|LOG_DEBUG, "test")
+	l.noclose = true
+	l.SetFormatter(CompactFormatter{})
+	child := l.With("k", "v")
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				if g%2 == 0 {
+					l.Info("parent")
+				} else {
+					child.Info("child")
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		if line != "6|parent" && line != "6|child k=v" {
+			t.Errorf("Expect: whole lines, get:%q", line)
+		}
+	}
+}
+
+func Test_withFields(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestFlog(&buf, LOG_LOCAL0|LOG_DEBUG, "test")
+	l.SetFormatter(CompactFormatter{})
+
+	e := l.WithFields(map[string]interface{}{"user": 7, "request_id": "abc"})
+	e.WithFields(map[string]interface{}{"ms": 12}).Info("done")
+
+	if s := buf.String(); s != "6|done request_id=abc user=7 ms=12\n" {
+		t.Errorf("Expect:%q, get:%q", "6|done request_id=abc user=7 ms=12\n", s)
+	}
+
+	buf.Reset()
+	l.SetFormat(FormatJSON)
+	e.Info("json")
+	if s := buf.String(); !strings.HasSuffix(s, `"msg":"json","request_id":"abc","user":7}`+"\n") {
+		t.Errorf("Expect: fields merged into object, get:%s", s)
+	}
+}
@@ -0,0 +1,56 @@
+package flog
+
+import (
+	"sort"
+)
+
+// RegisterErrorMapper 设置ErrErr使用的函数：根据err选择日志级别，
+// 并返回附加的字段(如错误码)。只使用返回值的级别部分，再次调用会替换之前的设置，
+// f为nil时恢复默认
+func (w *Flog) RegisterErrorMapper(f func(error) (Priority, map[string]string)) {
+	if f == nil {
+		w.errmap.Store(nil)
+		return
+	}
+	w.errmap.Store(&f)
+}
+
+// ErrErr 记录一个错误，默认为Err级别，err为nil时不记录
+func (w *Flog) ErrErr(err error) error {
+	return w.logError(err, nil, nil, nil)
+}
+
+func (e *Entry) ErrErr(err error) error {
+	return e.l.logError(err, e.fields, e.sd, e.named)
+}
+
+func (w *Flog) logError(err error, fields []Field, sd []SDElement, named *Named) error {
+	if err == nil {
+		return nil
+	}
+
+	p := LOG_ERR
+
+	if f := w.errmap.Load(); f != nil {
+		var m map[string]string
+		p, m = (*f)(err)
+
+		if len(m) > 0 {
+			keys := make([]string, 0, len(m))
+			for k := range m {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+
+			fs := make([]Field, 0, len(fields)+len(keys))
+			fs = append(fs, fields...)
+			for _, k := range keys {
+				fs = append(fs, Field{k, m[k]})
+			}
+			fields = fs
+		}
+	}
+
+	_, e := w.logRecord(&Record{Priority: p & severityMask, Msg: err.Error(), Fields: fields, SD: sd, named: named})
+	return e
+}
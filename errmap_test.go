@@ -0,0 +1,86 @@
+package flog
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+type notFoundError struct {
+	code string
+}
+
+func (e *notFoundError) Error() string {
+	return "not found"
+}
+
+func Test_errorMapper(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestFlog(&buf, LOG_LOCAL0|LOG_INFO, "test")
+	l.SetFormatter(CompactFormatter{})
+
+	l.ErrErr(errors.New("boom"))
+
+	l.RegisterErrorMapper(func(err error) (Priority, map[string]string) {
+		var nf *notFoundError
+		if errors.As(err, &nf) {
+			return LOG_WARNING, map[string]string{"code": nf.code}
+		}
+		return LOG_ERR, nil
+	})
+
+	l.ErrErr(&notFoundError{code: "E404"})
+	l.WithNewID("id").ErrErr(errors.New("internal"))
+
+	lines := buf.String()
+	if !bytes.HasPrefix(buf.Bytes(), []byte("3|boom\n4|not found code=E404\n3|internal id=")) {
+		t.Errorf("Expect: mapped severity and code, get:%q", lines)
+	}
+}
+
+func Test_errErrNil(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestFlog(&buf, LOG_LOCAL0|LOG_INFO, "test")
+	l.RegisterErrorMapper(func(err error) (Priority, map[string]string) {
+		t.Errorf("Expect: mapper not called for nil")
+		return LOG_ERR, nil
+	})
+
+	if err := l.ErrErr(nil); err != nil {
+		t.Errorf("Expect:nil, get:%v", err)
+	}
+	if err := l.With("k", "v").ErrErr(nil); err != nil {
+		t.Errorf("Expect:nil, get:%v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Expect: nothing logged, get:%q", buf.String())
+	}
+}
+
+func Test_errErrNamed(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestFlog(&buf, LOG_LOCAL0|LOG_INFO, "test")
+	l.SetFormatter(CompactFormatter{})
+	l.RegisterErrorMapper(func(err error) (Priority, map[string]string) {
+		return LOG_WARNING, nil
+	})
+
+	// 使用子日志的过滤级别
+	db := l.Named("db")
+	db.SetSeverity(LOG_ERR)
+	db.ErrErr(errors.New("filtered"))
+	l.Named("http").ErrErr(errors.New("timeout"))
+
+	// 并发替换不影响正在记录的日志
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		l.RegisterErrorMapper(nil)
+	}()
+	l.ErrErr(errors.New("any"))
+	<-done
+
+	if s := buf.String(); !bytes.HasPrefix(buf.Bytes(), []byte("4|timeout logger=http\n")) {
+		t.Errorf("Expect: named filter applied, get:%q", s)
+	}
+}
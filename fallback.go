@@ -0,0 +1,141 @@
+package flog
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 切换到备用目标后每隔这段时间重试一次更靠前的目标
+const fallbackRetry = 30 * time.Second
+
+// 当前目标连续失败这么多次才切换，偶尔一次失败不切换
+const fallbackFailures = 3
+
+type fallback struct {
+	mu      sync.Mutex
+	ws      []Writer
+	active  int
+	retryAt time.Time
+	now     func() time.Time
+	retry   time.Duration
+
+	threshold int
+	fails     int // 当前目标连续失败的次数
+}
+
+// Fallback 依次使用writers中的第一个可用目标，如 Fallback(file, syslog, stderr)：
+// 当前目标写入失败时这条日志改写到下一个目标；连续失败3次后切换到下一个目标，
+// 此后一直使用它，每30秒重试一次更靠前的目标，恢复后切换回去。
+// 每次切换在新目标上写一条Notice说明原因
+func Fallback(writers ...Writer) Writer {
+	return &fallback{ws: writers, now: time.Now, retry: fallbackRetry, threshold: fallbackFailures}
+}
+
+func (fb *fallback) do(f func(Writer) error) error {
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+
+	start := fb.active
+	if start > 0 && !fb.now().Before(fb.retryAt) {
+		start = 0
+	}
+
+	var errs []error
+	for i := start; i < len(fb.ws); i++ {
+		err := f(fb.ws[i])
+		if err == nil {
+			switch {
+			case i == fb.active:
+				fb.fails = 0
+			case i < fb.active || fb.fails >= fb.threshold:
+				fb.switchTo(i, errs)
+			}
+			return nil
+		}
+		errs = append(errs, err)
+
+		if i == fb.active {
+			fb.fails++
+		}
+
+		if i < fb.active {
+			// 重试失败，稍后再试
+			fb.retryAt = fb.now().Add(fb.retry)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// switchTo 切换到第i个目标，调用时必须持有mu
+func (fb *fallback) switchTo(i int, errs []error) {
+	old := fb.active
+	fb.active = i
+	fb.fails = 0
+	if i > 0 {
+		fb.retryAt = fb.now().Add(fb.retry)
+	}
+
+	var msg string
+	if i > old {
+		msg = fmt.Sprintf("flog: backend %d failed (%v), switched to backend %d", old, errs[len(errs)-1], i)
+	} else {
+		msg = fmt.Sprintf("flog: backend %d recovered, switched back from backend %d", i, old)
+	}
+	fb.ws[i].Notice(msg)
+}
+
+func (fb *fallback) Write(b []byte) (int, error) {
+	err := fb.do(func(w Writer) error {
+		_, err := w.Write(b)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// Close 关闭所有writer
+func (fb *fallback) Close() error {
+	var errs []error
+	for _, w := range fb.ws {
+		if err := w.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (fb *fallback) Emerg(m string) error {
+	return fb.do(func(w Writer) error { return w.Emerg(m) })
+}
+
+func (fb *fallback) Alert(m string) error {
+	return fb.do(func(w Writer) error { return w.Alert(m) })
+}
+
+func (fb *fallback) Crit(m string) error {
+	return fb.do(func(w Writer) error { return w.Crit(m) })
+}
+
+func (fb *fallback) Err(m string) error {
+	return fb.do(func(w Writer) error { return w.Err(m) })
+}
+
+func (fb *fallback) Warning(m string) error {
+	return fb.do(func(w Writer) error { return w.Warning(m) })
+}
+
+func (fb *fallback) Notice(m string) error {
+	return fb.do(func(w Writer) error { return w.Notice(m) })
+}
+
+func (fb *fallback) Info(m string) error {
+	return fb.do(func(w Writer) error { return w.Info(m) })
+}
+
+func (fb *fallback) Debug(m string) error {
+	return fb.do(func(w Writer) error { return w.Debug(m) })
+}
@@ -0,0 +1,99 @@
+package flog
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_fallback(t *testing.T) {
+	primary, second := new(failWriter), new(failWriter)
+	p := newFlog(primary, LOG_LOCAL0|LOG_INFO, "test")
+	s := newFlog(second, LOG_LOCAL0|LOG_INFO, "test")
+	p.SetFormatter(CompactFormatter{})
+	s.SetFormatter(CompactFormatter{})
+
+	w := Fallback(p, s)
+	c := &fakeClock{t: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	w.(*fallback).now = c.now
+	// 第一次失败就切换，阈值见Test_fallbackThreshold
+	w.(*fallback).threshold = 1
+
+	w.Info("one")
+	if got := primary.buf.String(); got != "6|one\n" {
+		t.Errorf("Expect:%q, get:%q", "6|one\n", got)
+	}
+
+	// 主目标失败，日志改写到备用目标
+	primary.buf.Reset()
+	primary.fail = true
+	if err := w.Info("two"); err != nil {
+		t.Errorf("Expect:nil, get:%v", err)
+	}
+	w.Info("three")
+	expect := "6|two\n5|flog: backend 0 failed (endpoint down), switched to backend 1\n6|three\n"
+	if got := second.buf.String(); got != expect {
+		t.Errorf("Expect:%q, get:%q", expect, got)
+	}
+
+	// 冷却期内不重试，之后重试失败仍留在备用目标
+	second.buf.Reset()
+	primary.fail = false
+	w.Info("four")
+	primary.fail = true
+	c.add(fallbackRetry)
+	w.Info("five")
+	if got := second.buf.String(); got != "6|four\n6|five\n" {
+		t.Errorf("Expect:%q, get:%q", "6|four\n6|five\n", got)
+	}
+
+	// 主目标恢复后切换回去
+	primary.fail = false
+	c.add(fallbackRetry)
+	w.Info("six")
+	expect = "6|six\n5|flog: backend 0 recovered, switched back from backend 1\n"
+	if got := primary.buf.String(); got != expect {
+		t.Errorf("Expect:%q, get:%q", expect, got)
+	}
+
+	// 全部失败时返回所有错误
+	primary.fail, second.fail = true, true
+	if err := w.Info("lost"); err == nil || strings.Count(err.Error(), "endpoint down") != 2 {
+		t.Errorf("Expect: both errors, get:%v", err)
+	}
+}
+
+func Test_fallbackThreshold(t *testing.T) {
+	primary, second := new(failWriter), new(failWriter)
+	p := newFlog(primary, LOG_LOCAL0|LOG_INFO, "test")
+	s := newFlog(second, LOG_LOCAL0|LOG_INFO, "test")
+	p.SetFormatter(CompactFormatter{})
+	s.SetFormatter(CompactFormatter{})
+	w := Fallback(p, s)
+
+	// 一次失败只把这条改写到备用目标，不切换
+	primary.fail = true
+	w.Info("transient")
+	primary.fail = false
+	w.Info("back")
+	if got := second.buf.String(); got != "6|transient\n" {
+		t.Errorf("Expect:%q, get:%q", "6|transient\n", got)
+	}
+	if got := primary.buf.String(); got != "6|back\n" {
+		t.Errorf("Expect:%q, get:%q", "6|back\n", got)
+	}
+
+	// 连续失败达到阈值后切换
+	second.buf.Reset()
+	primary.fail = true
+	for i := 0; i < fallbackFailures; i++ {
+		w.Info("down")
+	}
+	expect := strings.Repeat("6|down\n", fallbackFailures) + "5|flog: backend 0 failed (endpoint down), switched to backend 1\n"
+	if got := second.buf.String(); got != expect {
+		t.Errorf("Expect:%q, get:%q", expect, got)
+	}
+	if a := w.(*fallback).active; a != 1 {
+		t.Errorf("Expect: active 1, get:%d", a)
+	}
+}
@@ -0,0 +1,54 @@
+package flog
+
+import (
+	"fmt"
+	"os"
+	"runtime/debug"
+)
+
+// exit 测试时可以替换
+var exit = os.Exit
+
+// Fatal 以Crit级别记录m，关闭日志(写出所有缓存)，然后以状态1退出进程
+func (w *Flog) Fatal(m string) {
+	w.Crit(m)
+	w.Close()
+	exit(1)
+}
+
+// Fatalf 同Fatal，按format格式化消息
+func (w *Flog) Fatalf(format string, args ...interface{}) {
+	w.Fatal(fmt.Sprintf(format, args...))
+}
+
+func (e *Entry) Fatal(m string) {
+	e.Crit(m)
+	e.l.Close()
+	exit(1)
+}
+
+func (e *Entry) Fatalf(format string, args ...interface{}) {
+	e.Fatal(fmt.Sprintf(format, args...))
+}
+
+// RecoverAndLog 用于 defer l.RecoverAndLog()：捕获到panic时以Crit级别记录panic的值和调用栈，
+// 写出崩溃缓存和文件缓存，然后正常返回。与Recover不同，不再继续panic
+func (w *Flog) RecoverAndLog() {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	w.Crit(fmt.Sprintf("panic: %v\n%s", r, debug.Stack()))
+	w.DumpCrashRing()
+	w.Flush()
+}
+
+// WithStderrRedirect 让进程的stderr(文件描述符2)指向日志文件，
+// stderr被重定向到/dev/null时runtime的panic信息和调用栈也不会丢失。
+// 轮转或Reopen后指向新的文件。只对文件有效，不支持的平台上File返回ErrNotSupported
+func WithStderrRedirect() Option {
+	return func(w *Flog) {
+		w.opts.stderr = true
+	}
+}
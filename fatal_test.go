@@ -0,0 +1,84 @@
+package flog
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func Test_fatal(t *testing.T) {
+	old := exit
+	defer func() { exit = old }()
+
+	var code int
+	exit = func(c int) { code = c }
+
+	file := filepath.Join(t.TempDir(), "fatal.log")
+	l, err := File(file, LOG_LOCAL0|LOG_INFO, "test", WithBuffer(4096, 0), WithFormat(FormatCompact))
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+
+	l.With("k", "v").Fatalf("config %s missing", "db")
+	if code != 1 {
+		t.Errorf("Expect:1, get:%d", code)
+	}
+	if s := readFile(t, file); s != "2|config db missing k=v\n" {
+		t.Errorf("Expect:%q, get:%q", "2|config db missing k=v\n", s)
+	}
+	if err := l.Info("after"); err != ErrClosed {
+		t.Errorf("Expect:%v, get:%v", ErrClosed, err)
+	}
+}
+
+func Test_recoverAndLog(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestFlog(&buf, LOG_LOCAL0|LOG_INFO, "test")
+	l.SetFormatter(CompactFormatter{})
+
+	func() {
+		defer l.RecoverAndLog()
+		panic("boom")
+	}()
+
+	s := buf.String()
+	if !strings.HasPrefix(s, "2|panic: boom\ngoroutine ") || !strings.Contains(s, "Test_recoverAndLog") {
+		t.Errorf("Expect: panic with stack, get:%q", s)
+	}
+}
+
+func Test_stderrRedirect(t *testing.T) {
+	if file := os.Getenv("FLOG_TEST_STDERR"); file != "" {
+		l, err := File(file, LOG_LOCAL0|LOG_INFO, "test", WithStderrRedirect(), WithFormat(FormatCompact))
+		if err != nil {
+			os.Exit(3)
+		}
+		l.Info("before crash")
+		panic("crash into log")
+	}
+
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" && runtime.GOOS != "freebsd" {
+		t.Skip("not supported on " + runtime.GOOS)
+	}
+
+	file := filepath.Join(t.TempDir(), "crash.log")
+	cmd := exec.Command(os.Args[0], "-test.run", "^Test_stderrRedirect$")
+	cmd.Env = append(os.Environ(), "FLOG_TEST_STDERR="+file)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err == nil {
+		t.Fatalf("Expect: process crashed")
+	}
+
+	s := readFile(t, file)
+	if !strings.HasPrefix(s, "6|before crash\n") || !strings.Contains(s, "panic: crash into log") || !strings.Contains(s, "goroutine ") {
+		t.Errorf("Expect: panic output in log, get:%q", s)
+	}
+	if strings.Contains(stderr.String(), "crash into log") {
+		t.Errorf("Expect: panic not on original stderr, get:%q", stderr.String())
+	}
+}
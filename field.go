@@ -0,0 +1,43 @@
+package flog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Field 是附加在日志上的键值对
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+func fieldString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case bool:
+		return strconv.FormatBool(v)
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	}
+	return fmt.Sprint(v)
+}
+
+// appendField 以 key=value 形式追加字段，值含空白、引号或=时加引号
+func appendField(b []byte, f Field) []byte {
+	b = append(b, f.Key...)
+	b = append(b, '=')
+
+	v := fieldString(f.Value)
+	if v == "" || strings.ContainsAny(v, " \t\r\n\"=") {
+		return strconv.AppendQuote(b, v)
+	}
+	return append(b, v...)
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package flog
+
+import (
+	"errors"
+)
+
+func FIFO(filename string, priority Priority, tag string, opts ...Option) (*Flog, error) {
+	return nil, errors.New("flog: named pipes are not supported on this platform")
+}
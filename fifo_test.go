@@ -0,0 +1,60 @@
+//go:build unix
+
+package flog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func Test_fifo(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "log.fifo")
+	if err := syscall.Mkfifo(name, 0600); err != nil {
+		t.Skip(err)
+	}
+
+	start := time.Now()
+	l, err := FIFO(name, LOG_LOCAL0|LOG_INFO, "test")
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("Expect: non-blocking open")
+	}
+	l.SetFormatter(CompactFormatter{})
+
+	fw := l.w.(*fifoWriter)
+	if err := l.Info("lost"); err != nil {
+		t.Errorf("Expect:nil, get:%v", err)
+	}
+	if fw.dropped != 1 {
+		t.Errorf("Expect:1 dropped, get:%d", fw.dropped)
+	}
+
+	r, err := os.OpenFile(name, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	defer r.Close()
+
+	fw.next = time.Time{}
+	if err := l.Info("hello"); err != nil {
+		t.Errorf("Expect:nil, get:%v", err)
+	}
+
+	r.SetReadDeadline(time.Now().Add(time.Second))
+	buf := make([]byte, 64)
+	n, err := r.Read(buf)
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	if s := string(buf[:n]); s != "6|hello\n" || strings.Contains(s, "lost") {
+		t.Errorf("Expect:%q, get:%q", "6|hello\n", s)
+	}
+
+	l.Close()
+}
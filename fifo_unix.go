@@ -0,0 +1,93 @@
+//go:build unix
+
+package flog
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"time"
+)
+
+// fifoWriter 以O_NONBLOCK打开命名管道，没有读端时(ENXIO)丢弃日志并定期重试打开，
+// 打开后的写入由runtime的poller等待，对调用方是阻塞的
+type fifoWriter struct {
+	name    string
+	f       *os.File
+	retry   time.Duration
+	next    time.Time
+	dropped uint64
+}
+
+// FIFO 打开一个命名管道作为输出，没有读端时不会阻塞，
+// 期间的日志被丢弃，读端连上后恢复写入
+func FIFO(filename string, priority Priority, tag string, opts ...Option) (*Flog, error) {
+	fi, err := os.Stat(filename)
+	if err != nil {
+		return nil, err
+	}
+	if fi.Mode()&os.ModeNamedPipe == 0 {
+		return nil, errors.New("flog: " + filename + " is not a named pipe")
+	}
+
+	fw := &fifoWriter{name: filename, retry: time.Second}
+	if err := fw.open(); err != nil {
+		return nil, err
+	}
+
+	l := newFlog(fw, priority, tag)
+	l.apply(opts)
+	l.start()
+	return l, nil
+}
+
+func (fw *fifoWriter) open() error {
+	f, err := os.OpenFile(fw.name, os.O_WRONLY|syscall.O_NONBLOCK, 0)
+	if errors.Is(err, syscall.ENXIO) {
+		fw.next = time.Now().Add(fw.retry)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	fw.f = f
+	return nil
+}
+
+func (fw *fifoWriter) Write(p []byte) (int, error) {
+	if fw.f == nil {
+		if time.Now().Before(fw.next) {
+			fw.dropped++
+			return len(p), nil
+		}
+		if err := fw.open(); err != nil {
+			return 0, err
+		}
+		if fw.f == nil {
+			fw.dropped++
+			return len(p), nil
+		}
+	}
+
+	n, err := fw.f.Write(p)
+	if errors.Is(err, syscall.EPIPE) {
+		// 读端断开，回到等待读端的状态
+		fw.f.Close()
+		fw.f = nil
+		fw.next = time.Time{}
+		fw.dropped++
+		return len(p), nil
+	}
+	return n, err
+}
+
+func (fw *fifoWriter) Close() error {
+	if fw.f == nil {
+		return nil
+	}
+
+	err := fw.f.Close()
+	fw.f = nil
+	return err
+}
@@ -0,0 +1,321 @@
+package flog
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// fileWriter 是File的输出，负责按大小和日期轮转、压缩备份。
+// 所有方法都在持有Flog.mu时调用
+type fileWriter struct {
+	name string
+	flag int
+	f    *os.File
+	buf  *bufio.Writer
+	size int64
+	opts *options
+
+	// 文件名中含有 %Y %m %d %H 时按时间切换文件
+	pattern string
+	hourly  bool
+	period  int
+	now     func() time.Time
+
+	// 压缩中的备份，备份的改名和压缩完成时的改名都持有cmu
+	cmu  sync.Mutex
+	jobs []*compressJob
+	wg   sync.WaitGroup
+
+	// 最后一次轮转或按时间切换文件的时间(UnixNano)，见Stats
+	rotated atomic.Int64
+}
+
+func openFileWriter(name string, flag int, opts *options, now func() time.Time) (*fileWriter, error) {
+	fw := &fileWriter{name: name, flag: flag, opts: opts}
+	if strings.ContainsRune(name, '%') {
+		fw.pattern = name
+		fw.hourly = strings.Contains(name, "%H")
+		fw.now = now
+		fw.name, fw.period = fw.target()
+	}
+	if err := fw.open(); err != nil {
+		return nil, err
+	}
+	return fw, nil
+}
+
+func (fw *fileWriter) open() error {
+	f, err := os.OpenFile(fw.name, fw.flag, 0666)
+	if err != nil {
+		return err
+	}
+
+	// 以O_APPEND打开，从已有的大小开始计数
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	if fw.opts.stderr {
+		if err := redirectStderr(f); err != nil {
+			f.Close()
+			return err
+		}
+	}
+
+	fw.f = f
+	fw.size = fi.Size()
+	if fw.opts.buffer > 0 {
+		if fw.buf == nil {
+			fw.buf = bufio.NewWriterSize(f, fw.opts.buffer)
+		} else {
+			fw.buf.Reset(f)
+		}
+	}
+	return nil
+}
+
+// target 返回当前时间对应的文件名和时间段
+func (fw *fileWriter) target() (string, int) {
+	t := fw.now()
+	if fw.opts.location != nil {
+		t = t.In(fw.opts.location)
+	}
+
+	y, m, d := t.Date()
+	period := (y*100+int(m))*100 + d
+	if fw.hourly {
+		period = period*100 + t.Hour()
+	}
+
+	if period == fw.period {
+		return fw.name, period
+	}
+	return strftime(fw.pattern, t), period
+}
+
+// switchFile 跨过日期边界时关闭旧文件打开新文件，
+// 只比较缓存的时间段，没有跨过边界时不做系统调用
+func (fw *fileWriter) switchFile() error {
+	name, period := fw.target()
+	if period == fw.period {
+		return nil
+	}
+	fw.period = period
+
+	if name == fw.name {
+		return nil
+	}
+
+	// 关闭出错(旧句柄已不可用)也照常切换并打开新文件
+	old := fw.name
+	err := fw.closeFile()
+
+	fw.cmu.Lock()
+	fw.name = name
+	if fw.opts.compress {
+		fw.compress(old, 0)
+	}
+	fw.retain()
+	fw.cmu.Unlock()
+
+	fw.rotated.Store(time.Now().UnixNano())
+	if err1 := fw.open(); err1 != nil {
+		return err1
+	}
+	return err
+}
+
+func (fw *fileWriter) Write(p []byte) (int, error) {
+	// 切换或轮转失败时文件已重新打开，本条仍然写入，返回轮转的错误
+	var rerr error
+	if fw.pattern != "" {
+		rerr = fw.switchFile()
+	}
+
+	if max := fw.opts.maxSize; rerr == nil && max > 0 && fw.size > 0 && fw.size+int64(len(p)) > max {
+		rerr = fw.rotate()
+	}
+
+	var n int
+	var err error
+	if fw.buf != nil {
+		n, err = fw.buf.Write(p)
+	} else {
+		n, err = fw.f.Write(p)
+	}
+	fw.size += int64(n)
+	if err == nil {
+		err = rerr
+	}
+	return n, err
+}
+
+func (fw *fileWriter) flush() error {
+	if fw.buf == nil {
+		return nil
+	}
+	return fw.buf.Flush()
+}
+
+// closeFile 写出缓存后关闭当前文件
+func (fw *fileWriter) closeFile() error {
+	err := fw.flush()
+	if err1 := fw.f.Close(); err == nil {
+		err = err1
+	}
+	return err
+}
+
+func (fw *fileWriter) Close() error {
+	return fw.closeFile()
+}
+
+func (fw *fileWriter) datasync() error {
+	if err := fw.flush(); err != nil {
+		return err
+	}
+	return datasync(fw.f)
+}
+
+func (fw *fileWriter) backupName(i int) string {
+	return fw.name + "." + strconv.Itoa(i)
+}
+
+// rotate 把当前文件改名为name.1，已有备份依次后移，超出MaxBackups的删除
+func (fw *fileWriter) rotate() error {
+	err := fw.closeFile()
+
+	fw.cmu.Lock()
+	serr := fw.shift()
+	if serr == nil && fw.opts.compress {
+		fw.compress(fw.name, 1)
+	}
+	fw.retain()
+	fw.cmu.Unlock()
+	if serr == nil {
+		fw.rotated.Store(time.Now().UnixNano())
+	} else if err == nil {
+		err = serr
+	}
+
+	// 无论是否改名成功都重新打开，改名失败时继续追加到原文件
+	if err1 := fw.open(); err1 != nil {
+		return err1
+	}
+	return err
+}
+
+// shift 把当前文件改名为name.1，已有备份(包括.gz和索引)依次后移，
+// 超出MaxBackups的删除。调用时必须持有cmu
+func (fw *fileWriter) shift() error {
+	n := fw.opts.maxBackups
+	if n <= 0 {
+		n = 1
+		for fw.backupExists(n) {
+			n++
+		}
+	} else {
+		os.Remove(fw.backupName(n))
+		os.Remove(fw.backupName(n) + ".gz")
+		os.Remove(fw.backupName(n) + ".gz.idx")
+	}
+
+	for i := n - 1; i >= 1; i-- {
+		for _, ext := range []string{"", ".gz", ".gz.idx"} {
+			if exists(fw.backupName(i) + ext) {
+				os.Rename(fw.backupName(i)+ext, fw.backupName(i+1)+ext)
+			}
+		}
+	}
+
+	for _, j := range fw.jobs {
+		if j.base == fw.name {
+			j.index++
+		}
+	}
+
+	if err := os.Rename(fw.name, fw.backupName(1)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (fw *fileWriter) backupExists(i int) bool {
+	return exists(fw.backupName(i)) || exists(fw.backupName(i)+".gz")
+}
+
+func exists(name string) bool {
+	_, err := os.Lstat(name)
+	return err == nil
+}
+
+// strftime 支持 %Y %m %d %H 和 %%，其它原样保留
+func strftime(pattern string, t time.Time) string {
+	b := make([]byte, 0, len(pattern)+8)
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		if c != '%' || i+1 == len(pattern) {
+			b = append(b, c)
+			continue
+		}
+
+		i++
+		switch pattern[i] {
+		case 'Y':
+			b = t.AppendFormat(b, "2006")
+		case 'm':
+			b = t.AppendFormat(b, "01")
+		case 'd':
+			b = t.AppendFormat(b, "02")
+		case 'H':
+			b = t.AppendFormat(b, "15")
+		case '%':
+			b = append(b, '%')
+		default:
+			b = append(b, '%', pattern[i])
+		}
+	}
+	return string(b)
+}
+
+// reopen 关闭当前文件后按原文件名重新打开
+func (fw *fileWriter) reopen() error {
+	err := fw.closeFile()
+	if err1 := fw.open(); err1 != nil {
+		return err1
+	}
+	return err
+}
+
+// Reopen 关闭并重新打开日志文件，配合logrotate等外部轮转使用：
+//
+//	c := make(chan os.Signal, 1)
+//	signal.Notify(c, syscall.SIGHUP)
+//	go func() {
+//		for range c {
+//			l.Reopen()
+//		}
+//	}()
+//
+// 不是写文件的日志(stderr、stdout等)直接返回nil
+func (w *Flog) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return ErrClosed
+	}
+	if w.file == nil {
+		return nil
+	}
+
+	defer w.lockFile()()
+	return w.file.reopen()
+}
@@ -0,0 +1,290 @@
+package flog
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func readFile(t *testing.T, name string) string {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Errorf("Expect:nil, get:%v", err)
+	}
+	return string(data)
+}
+
+func Test_rotateSize(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.log")
+
+	// 已有内容计入大小
+	os.WriteFile(file, []byte("0|old\n"), 0666)
+
+	l, err := File(file, LOG_LOCAL0|LOG_INFO, "test", WithMaxSize(20), WithMaxBackups(2))
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	defer l.Close()
+	l.SetFormatter(CompactFormatter{})
+
+	// 每行9字节，每个文件最多2行，已有内容与line-0在一个文件
+	for i := 0; i < 7; i++ {
+		l.Info("line-" + strconv.Itoa(i))
+	}
+
+	expect := map[string]string{
+		"app.log":   "6|line-5\n6|line-6\n",
+		"app.log.1": "6|line-3\n6|line-4\n",
+		"app.log.2": "6|line-1\n6|line-2\n",
+	}
+	for name, content := range expect {
+		if s := readFile(t, filepath.Join(dir, name)); s != content {
+			t.Errorf("%s Expect:%q, get:%q", name, content, s)
+		}
+	}
+
+	if exists(filepath.Join(dir, "app.log.3")) {
+		t.Errorf("Expect: app.log.3 removed by MaxBackups")
+	}
+}
+
+func Test_rotateRenameFail(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.log")
+
+	// app.log.1是非空目录，改名会失败
+	os.MkdirAll(filepath.Join(dir, "app.log.1", "x"), 0777)
+
+	l, err := File(file, LOG_LOCAL0|LOG_INFO, "test", WithMaxSize(20), WithMaxBackups(1))
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	defer l.Close()
+	l.SetFormatter(CompactFormatter{})
+
+	for i := 0; i < 4; i++ {
+		l.Info("line-" + strconv.Itoa(i))
+	}
+	if s := l.Stats(); s.WriteErrors == 0 {
+		t.Errorf("Expect: rotate errors counted, get:%+v", s)
+	}
+
+	// 改名失败时继续写原文件，不丢日志
+	expect := "6|line-0\n6|line-1\n6|line-2\n6|line-3\n"
+	if s := readFile(t, file); s != expect {
+		t.Errorf("Expect:%q, get:%q", expect, s)
+	}
+
+	// 障碍移除后恢复轮转
+	os.RemoveAll(filepath.Join(dir, "app.log.1"))
+	l.Info("line-4")
+	if s := readFile(t, file); s != "6|line-4\n" {
+		t.Errorf("Expect:%q, get:%q", "6|line-4\n", s)
+	}
+	if s := readFile(t, file+".1"); s != expect {
+		t.Errorf("Expect:%q, get:%q", expect, s)
+	}
+}
+
+func Test_rotateConcurrent(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.log")
+
+	l, err := File(file, LOG_LOCAL0|LOG_INFO, "test", WithMaxSize(1000), WithDatasync(0))
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	l.SetFormatter(CompactFormatter{})
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				l.Info("concurrent rotation")
+			}
+		}()
+	}
+	wg.Wait()
+	l.Close()
+
+	files, _ := filepath.Glob(file + "*")
+	total := 0
+	for _, f := range files {
+		s := readFile(t, f)
+		if len(s) > 1000 {
+			t.Errorf("%s Expect:<=1000 bytes, get:%d", f, len(s))
+		}
+		for _, line := range strings.Split(strings.TrimSuffix(s, "\n"), "\n") {
+			if line != "6|concurrent rotation" {
+				t.Errorf("%s broken line %q", f, line)
+			}
+			total++
+		}
+	}
+	if total != 400 {
+		t.Errorf("Expect:400 lines, get:%d", total)
+	}
+}
+
+func Test_rotateDaily(t *testing.T) {
+	dir := t.TempDir()
+	loc := time.FixedZone("UTC+8", 8*3600)
+
+	l, err := File(filepath.Join(dir, "app-%Y-%m-%d.log"), LOG_LOCAL0|LOG_INFO, "test", WithLocation(loc))
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	defer l.Close()
+	l.SetFormatter(CompactFormatter{})
+
+	// 2020-01-01 23:59 UTC+8 是 15:59 UTC
+	c := &fakeClock{t: time.Date(2020, 1, 1, 15, 59, 0, 0, time.UTC)}
+	l.now = c.now
+
+	l.Info("day1")
+	c.add(30 * time.Second)
+	l.Info("day1 again")
+
+	// 空闲跨过午夜后的第一条写入新文件
+	c.add(3 * time.Hour)
+	l.Info("day2")
+
+	expect := map[string]string{
+		"app-2020-01-01.log": "6|day1\n6|day1 again\n",
+		"app-2020-01-02.log": "6|day2\n",
+	}
+	for name, content := range expect {
+		if s := readFile(t, filepath.Join(dir, name)); s != content {
+			t.Errorf("%s Expect:%q, get:%q", name, content, s)
+		}
+	}
+}
+
+func Test_rotateDailyMaxBackups(t *testing.T) {
+	dir := t.TempDir()
+
+	l, err := File(filepath.Join(dir, "app-%Y-%m-%d.log"), LOG_LOCAL0|LOG_INFO, "test", WithLocation(time.UTC), WithMaxBackups(2))
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	defer l.Close()
+	l.SetFormatter(CompactFormatter{})
+
+	// 打开时已按真实时间创建了今天的文件，从一年后开始它就是最旧的
+	start := time.Now().UTC().AddDate(1, 0, 0)
+	c := &fakeClock{t: start}
+	l.now = c.now
+
+	for i := 0; i < 5; i++ {
+		l.Info("day" + strconv.Itoa(i+1))
+		c.add(24 * time.Hour)
+	}
+
+	// 当前文件加最近2个旧文件
+	files, _ := filepath.Glob(filepath.Join(dir, "app-*.log"))
+	var expect []string
+	for i := 2; i < 5; i++ {
+		expect = append(expect, start.AddDate(0, 0, i).Format("app-2006-01-02.log"))
+	}
+	if len(files) != len(expect) {
+		t.Fatalf("Expect:%v, get:%v", expect, files)
+	}
+	for i, f := range files {
+		if filepath.Base(f) != expect[i] {
+			t.Errorf("Expect:%s, get:%s", expect[i], filepath.Base(f))
+		}
+	}
+}
+
+func Test_strftime(t *testing.T) {
+	tm := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	tests := map[string]string{
+		"app-%Y-%m-%d.log": "app-2021-03-04.log",
+		"app-%Y%m%d%H.log": "app-2021030405.log",
+		"100%%-%x%":        "100%-%x%",
+	}
+	for in, expect := range tests {
+		if s := strftime(in, tm); s != expect {
+			t.Errorf("Expect:%s, get:%s", expect, s)
+		}
+	}
+}
+
+func Test_reopen(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.log")
+
+	l, err := File(file, LOG_LOCAL0|LOG_INFO, "test")
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	defer l.Close()
+	l.SetFormatter(CompactFormatter{})
+
+	l.Info("before")
+	os.Rename(file, file+".old")
+	l.Info("orphaned")
+
+	if err := l.Reopen(); err != nil {
+		t.Errorf("Expect:nil, get:%v", err)
+	}
+	l.Info("after")
+
+	if s := readFile(t, file+".old"); s != "6|before\n6|orphaned\n" {
+		t.Errorf("Expect:%q, get:%q", "6|before\n6|orphaned\n", s)
+	}
+	if s := readFile(t, file); s != "6|after\n" {
+		t.Errorf("Expect:%q, get:%q", "6|after\n", s)
+	}
+}
+
+func Test_reopenConcurrent(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.log")
+
+	l, err := File(file, LOG_LOCAL0|LOG_INFO, "test")
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	l.SetFormatter(CompactFormatter{})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			if err := l.Info("msg"); err != nil {
+				t.Errorf("Expect:nil, get:%v", err)
+			}
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		l.Reopen()
+	}
+	wg.Wait()
+	l.Close()
+
+	if s := readFile(t, file); strings.Count(s, "6|msg\n") != 100 {
+		t.Errorf("Expect:100 lines, get:%q", s)
+	}
+	if err := l.Reopen(); err != ErrClosed {
+		t.Errorf("Expect:%v, get:%v", ErrClosed, err)
+	}
+}
+
+func Test_reopenStderr(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestFlog(&buf, LOG_LOCAL0|LOG_INFO, "test")
+
+	if err := l.Reopen(); err != nil {
+		t.Errorf("Expect:nil, get:%v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package flog
+
+// filterWriter 只把不低于max级别的日志交给Writer
+type filterWriter struct {
+	w   Writer
+	max Priority
+}
+
+// Filter 返回只写入severity不低于max(数值不大于max)的日志的w，
+// 用于给自己没有过滤级别的Writer(如*syslog.Writer)单独设置级别：
+//
+//	Multi(file, Filter(sys, LOG_NOTICE))
+//
+// Write没有级别，总是写入
+func Filter(w Writer, max Priority) Writer {
+	return &filterWriter{w: w, max: max & severityMask}
+}
+
+func (f *filterWriter) enabled(p Priority) bool {
+	if p&severityMask > f.max {
+		return false
+	}
+	if e, ok := f.w.(interface{ enabled(Priority) bool }); ok {
+		return e.enabled(p)
+	}
+	return true
+}
+
+func (f *filterWriter) log(p Priority, m string) error {
+	if p&severityMask > f.max {
+		return nil
+	}
+	return writeTo(f.w, p, m)
+}
+
+func (f *filterWriter) Write(b []byte) (int, error) {
+	return f.w.Write(b)
+}
+
+func (f *filterWriter) Close() error {
+	return f.w.Close()
+}
+
+func (f *filterWriter) Emerg(m string) error {
+	return f.log(LOG_EMERG, m)
+}
+
+func (f *filterWriter) Alert(m string) error {
+	return f.log(LOG_ALERT, m)
+}
+
+func (f *filterWriter) Crit(m string) error {
+	return f.log(LOG_CRIT, m)
+}
+
+func (f *filterWriter) Err(m string) error {
+	return f.log(LOG_ERR, m)
+}
+
+func (f *filterWriter) Warning(m string) error {
+	return f.log(LOG_WARNING, m)
+}
+
+func (f *filterWriter) Notice(m string) error {
+	return f.log(LOG_NOTICE, m)
+}
+
+func (f *filterWriter) Info(m string) error {
+	return f.log(LOG_INFO, m)
+}
+
+func (f *filterWriter) Debug(m string) error {
+	return f.log(LOG_DEBUG, m)
+}
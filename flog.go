@@ -1,18 +1,22 @@
 package flog
 
 import (
-	"fmt"
+	"context"
 	"os"
 	"io"
 	"strings"
-	"regexp"
 	"sync"
+	"sync/atomic"
 	"time"
-	"log/syslog"
-	"net/url"
 	"errors"
+	"fmt"
 )
 
+var ErrClosed = errors.New("flog: closed")
+
+// ErrNotSupported 在没有syslog的平台(如Windows)上由Dial返回
+var ErrNotSupported = errors.New("flog: syslog not supported on this platform")
+
 type Priority int
 
 const severityMask = 0x07
@@ -76,93 +80,320 @@ type Flog struct {
 	mu   sync.Mutex
 	w io.WriteCloser
 	noclose bool
+	now func() time.Time
+	rates [8]ewma
+	formatter atomic.Pointer[formatterBox]
+	termSafe atomic.Bool
+	opts options
+	done chan struct{}
+	stopOnce sync.Once
+	dirty bool
+	// 后台datasync、定时写出缓存的goroutine已经启动
+	syncing, flushing bool
+	ring *ring
+	crashFile string
+	idgen atomic.Pointer[func() string]
+	delta bool
+	last time.Time
+	errmap atomic.Pointer[func(error) (Priority, map[string]string)]
+	maxLine int
+	stats Stats
+	fmtPanics atomic.Uint64
+	routes []route
+	routeMode RouteMode
+	sd atomic.Pointer[[]SDElement]
+	mws atomic.Pointer[[]Middleware]
+	hooks atomic.Pointer[[]Hook]
+	hookErrs atomic.Uint64
+	spool *spool
+	recorder *Recorder
+	closed bool
+	file *fileWriter
+	async *asyncWriter
+	timeFmt atomic.Pointer[timeFormat]
+	limits [8]*rateLimit
+	cfg *Config
+	prefixes atomic.Bool
+	caller atomic.Int32
+	named map[string]*Named
+	levels map[string]Priority
+	extractors atomic.Pointer[[]func(context.Context) []Field]
+	redacts atomic.Pointer[[]redactRule]
+}
+
+func newFlog(w io.WriteCloser, priority Priority, tag string) *Flog {
+	l := new(Flog)
+	l.init(w, priority, priority, tag)
+	return l
+}
+
+func (l *Flog) init(w io.WriteCloser, priority, filter Priority, tag string) {
+	l.w = w
+	l.priority = priority
+	l.filter = (filter & severityMask)
+	l.tag = cleanTag(tag)
+	l.now = time.Now
+	l.SetFormatter(HumanFormatter{})
+	l.done = make(chan struct{})
 }
 
-func New(filename, priority, tag string) (Writer, error) {
-	_p := log_level(priority)
-	if _p == 0 {
-		return nil, errors.New("Priority Error")
+// New 按filename创建日志：""或"<stderr>"、"<stdout>"、"<syslog>"、"<journald>"、文件路径，
+// 或file://、stderr://、syslog://、journald://、tcp://等URL，后面可以带level、tag、format、
+// rotate、maxsize等参数，见parseDSN，如 file:///var/log/app.log?level=debug&rotate=daily。
+// 参数中的level和tag优先于priority和tag，opts在参数之后应用。
+// 网络地址见DialNet；stderr、stdout是终端时默认使用ConsoleFormatter。
+// opts对本机syslog无效
+func New(filename, priority, tag string, opts ...Option) (Writer, error) {
+	d, err := parseDSN(filename)
+	if err != nil {
+		return nil, err
+	}
+	if d.level != "" {
+		priority = d.level
+	}
+	if d.tag != "" {
+		tag = d.tag
 	}
 
-	switch filename {
-	case "" : fallthrough
-	case "<stderr>" :
-		l := new(Flog)
-		l.w = os.Stderr
-		l.priority = _p
-		l.filter = (_p & severityMask)
-		l.tag = tag
-		l.noclose = true
-		return l, nil
-	case "<stdout>" :
-		l := new(Flog)
-		l.w = os.Stdout
-		l.priority = _p
-		l.filter = (_p & severityMask)
-		l.tag = tag
-		l.noclose = true
-		return l, nil
-	case "<syslog>" :
+	_p, err := log_level(priority)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(d.opts, opts...)
+
+	switch d.scheme {
+	case "stderr" :
+		return console(os.Stderr, _p, tag, opts), nil
+	case "stdout" :
+		return console(os.Stdout, _p, tag, opts), nil
+	case "syslog" :
 		return Dial("", "", _p, tag)
+	case "journald":
+		return DialJournal(_p, tag, opts...)
+	case "file" :
+		return File(d.addr, _p, tag, opts...)
 	default:
-		ok, _ := regexp.MatchString(`^\w\+`, filename)
-		if ok {
-			u, err := url.Parse(filename)
-			if err != nil {
-				return nil, err
-			}
-			return Dial(u.Scheme, u.Host, _p, tag)
-		} else {
-			return File(filename, _p, tag)
-		}
+		return DialNet(d.scheme, d.addr, _p, tag, opts...)
 	}
 }
 
-func Dial(network, raddr string, priority Priority, tag string) (*syslog.Writer, error) {
-	return syslog.Dial(network, raddr, syslog.Priority(priority), tag)
+// Open 只用一个DSN创建日志，同New(dsn, "", "", opts...)，
+// 如 Open("tcp://10.0.0.1:514?level=warning&tag=app&format=rfc5424")
+func Open(dsn string, opts ...Option) (Writer, error) {
+	return New(dsn, "", "", opts...)
+}
+
+// console 创建写入stderr或stdout的日志，f是终端时默认使用ConsoleFormatter
+func console(f *os.File, priority Priority, tag string, opts []Option) *Flog {
+	l := newFlog(f, priority, tag)
+	l.noclose = true
+	if cf, ok := consoleFormatter(f); ok {
+		l.SetFormatter(cf)
+	}
+	l.apply(opts)
+	l.start()
+	return l
 }
 
-func File(filename string, priority Priority, tag string) (w *Flog, err error) {
-	f, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_SYNC, 0666)
+// File 写入文件，文件名中可以含有 %Y %m %d %H，
+// 如 /var/log/app-%Y-%m-%d.log 每天一个文件
+func File(filename string, priority Priority, tag string, opts ...Option) (w *Flog, err error) {
+	l := newFlog(nil, priority, tag)
+	l.apply(opts)
+
+	flag := os.O_WRONLY|os.O_APPEND|os.O_CREATE
+	if l.opts.datasync <= 0 && l.opts.buffer <= 0 {
+		flag |= os.O_SYNC
+	}
+
+	fw, err := openFileWriter(filename, flag, &l.opts, func() time.Time { return l.now() })
 	if err != nil {
 		return nil, err
 	}
+	l.w = fw
+	l.file = fw
 
-	l := new(Flog)
-	l.w = f
-	l.priority = priority
-	l.filter = (priority & severityMask)
-	l.tag = tag
+	if l.opts.datasync > 0 {
+		l.goDatasync(l.opts.datasync)
+	}
+	if l.opts.buffer > 0 && l.opts.flushEvery > 0 {
+		l.goFlush(l.opts.flushEvery)
+	}
+
+	l.start()
+	return l, nil
+}
+
+// NewFromWriter 创建写入w的日志，如bytes.Buffer或管道。
+// Close时会关闭w，os.Stderr和os.Stdout除外
+func NewFromWriter(w io.WriteCloser, priority, filter Priority, tag string, opts ...Option) (*Flog, error) {
+	if w == nil {
+		return nil, errors.New("flog: nil writer")
+	}
+
+	l := new(Flog).Init("", w, priority, filter, tag)
+	l.apply(opts)
+	l.start()
 	return l, nil
 }
 
+// Init 初始化一个新的Flog：w不为nil时写入w，否则以追加方式打开file，
+// 打开失败时返回nil。不能对正在使用的Flog调用
 func (l *Flog) Init(file string, w io.WriteCloser, priority, filter Priority, tag string) *Flog {
+	l.init(w, priority, filter, tag)
+
+	if w == nil {
+		fw, err := openFileWriter(file, os.O_WRONLY|os.O_APPEND|os.O_CREATE|os.O_SYNC, &l.opts, func() time.Time { return l.now() })
+		if err != nil {
+			return nil
+		}
+		w = fw
+		l.w = fw
+		l.file = fw
+	}
 
+	l.noclose = w == os.Stderr || w == os.Stdout
 	return l
 }
 
+// SetTag 设置tag，tag中的 [ ] : 、空白和控制字符会被替换成 _ ，
+// 以保证 tag[pid]: 的格式可以被解析
 func (w *Flog) SetTag(tag string) {
-	w.tag = tag
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.tag = cleanTag(tag)
+}
+
+// SetFormatter 可以在写日志的同时调用，每条日志只会由一个Formatter完整格式化。
+// f为nil时恢复默认格式
+func (w *Flog) SetFormatter(f Formatter) {
+	if f == nil {
+		f = HumanFormatter{}
+	}
+	w.formatter.Store(&formatterBox{f})
+}
+
+// SetFormat 切换到内置的输出格式，如FormatJSON
+func (w *Flog) SetFormat(f Format) {
+	w.SetFormatter(f.Formatter())
+}
+
+func (w *Flog) getFormatter() Formatter {
+	return w.formatter.Load().f
+}
+
+// SetTerminalSafe 开启后消息中的ANSI转义序列和控制字符会被转义，
+// 防止通过日志注入终端控制指令
+func (w *Flog) SetTerminalSafe(on bool) {
+	w.termSafe.Store(on)
 }
 
+// SetPriority 可以在写日志的同时调用
 func (w *Flog) SetPriority(priority, filter Priority) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
 	w.priority = priority
-	w.filter = filter
+	w.filter = filter & severityMask
+}
+
+// SetLevel 按New的priority格式(如"daemon:debug")设置priority和过滤级别
+func (w *Flog) SetLevel(level string) error {
+	p, err := log_level(level)
+	if err != nil {
+		return err
+	}
+
+	w.SetPriority(p, p&severityMask)
+	return nil
+}
+
+// level 返回当前的priority、过滤级别和tag
+func (w *Flog) level() (priority, filter Priority, tag string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.priority, w.filter, w.tag
+}
+
+func (w *Flog) getPriority() Priority {
+	p, _, _ := w.level()
+	return p
 }
 
 func (w *Flog) Write(b []byte) (int, error) {
-	return w.writeAndRetry(w.priority, string(b))
+	p, s := w.getPriority(), string(b)
+	if w.prefixes.Load() {
+		if sev, rest, ok := detectSeverity(s); ok {
+			p, s = p&facilityMask|sev, rest
+		}
+	}
+	return w.writeAndRetry(p, s)
 }
 
+// Close 写出缓存的内容并关闭底层输出，异步写入时先等待队列写完。
+// 底层输出只会被关闭一次，可以并发调用，重复调用返回nil，之后的写入返回ErrClosed。
+// stderr、stdout不会被关闭，只写出缓存的内容
 func (w *Flog) Close() error {
-	if w.noclose {
-		return nil
+	if w.done != nil {
+		w.stop()
+	}
+
+	// 等待后台压缩完成
+	if w.file != nil {
+		defer w.file.waitCompress()
 	}
 
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
-	return w.Close()
+	if w.closed {
+		return nil
+	}
+	if w.w == nil {
+		// 未初始化的Flog
+		w.closed = true
+		return nil
+	}
+
+	// 有路由时在持有mu的情况下发给路由，路由的目标是其它Writer
+	for p := range w.limits {
+		if r := w.suppressedRecord(Priority(p)); r != nil {
+			if targets := w.suppressedTargets(r); len(targets) > 0 {
+				w.dispatch(targets, r)
+			}
+		}
+	}
+	w.closed = true
+
+	var err error
+	if w.async != nil {
+		// 合并写入缓存的内容要先进入队列
+		if c, ok := w.w.(*coalescer); ok {
+			c.flush()
+		}
+		err = w.async.drain()
+	}
+
+	// 只有文件才有datasync，其它输出忽略WithDatasync
+	if w.opts.datasync > 0 && w.dirty && w.file != nil {
+		w.file.datasync()
+	}
+
+	var err1 error
+	if w.noclose {
+		if f, ok := w.w.(interface{ flush() error }); ok {
+			err1 = f.flush()
+		}
+	} else {
+		err1 = w.w.Close()
+	}
+
+	if err == nil {
+		err = err1
+	}
+	return err
 }
 
 func (w *Flog) Emerg(m string) (err error) {
@@ -206,36 +437,224 @@ func (w *Flog) Debug(m string) (err error) {
 }
 
 func (w *Flog) writeAndRetry(p Priority, s string) (int, error) {
-	tp := p & severityMask
-	if w.filter < tp {
+	return w.log(p, s, nil)
+}
+
+// log 被过滤的日志在这里返回，不分配内存
+func (w *Flog) log(p Priority, s string, fields []Field) (int, error) {
+	if !w.enabled(p) {
+		return 0, nil
+	}
+
+	r := getRecord()
+	r.Priority, r.Msg, r.Fields = p, s, fields
+	n, err := w.logRecord(r)
+	putRecord(r)
+	return n, err
+}
+
+func (w *Flog) logRecord(r *Record) (int, error) {
+	if !w.resolve(r) {
 		return 0, nil
 	}
 
-	pr := (w.priority & facilityMask) | tp
+	hooks := w.hooks.Load()
+	if hooks != nil && r.Time.IsZero() {
+		r.Time = w.now()
+	}
+
+	n, ok, err := w.output(r)
+	if ok && hooks != nil {
+		w.fire(*hooks, r)
+	}
+	return n, err
+}
+
+// output 把日志交给路由或写入输出，ok为false表示被限流丢弃
+func (w *Flog) output(r *Record) (n int, ok bool, err error) {
+	return w.outputLine(r, nil)
+}
 
+// outputLine 同output，line不为nil时是已经格式化好的r，不再格式化
+func (w *Flog) outputLine(r *Record, line []byte) (n int, ok bool, err error) {
 	w.mu.Lock()
-	defer w.mu.Unlock()
+	targets, suppressed, ok, err := w.outputLocked(r, line)
+	w.mu.Unlock()
+
+	if targets != nil {
+		if suppressed != nil {
+			w.dispatch(targets, suppressed)
+		}
+		_, err = w.dispatch(targets, r)
+	}
+	if !ok || err != nil {
+		return 0, ok, err
+	}
+	return len(r.Msg), true, nil
+}
+
+// outputLocked 是outputLine中持有mu的部分，调用时必须持有mu。
+// 需要路由的日志不在这里写出，返回路由目标和限流摘要，由调用方解锁后分发
+func (w *Flog) outputLocked(r *Record, line []byte) (targets []Writer, suppressed *Record, ok bool, err error) {
+	allowed, suppressed := w.allow(r.Priority)
+	if !allowed {
+		w.stats.Dropped[r.Priority&severityMask]++
+		return nil, nil, false, nil
+	}
+	if len(w.routes) > 0 {
+		if targets := w.routeTargets(r.Priority); len(targets) > 0 {
+			return targets, suppressed, true, nil
+		}
+	}
+
+	w.rates[r.Priority&severityMask].add(w.now())
 
-	return w.write(pr, s)
+	if suppressed != nil {
+		w.write(suppressed)
+	}
+	if line != nil {
+		err = w.writeRecord(r, line)
+	} else {
+		_, err = w.write(r)
+	}
+	return nil, nil, true, err
 }
 
-func (w *Flog) write(p Priority, msg string) (int, error) {
-	nl := ""
-	if !strings.HasSuffix(msg, "\n") {
-		nl = "\n"
+// resolve 补全priority的facility部分并执行中间件，
+// 返回false表示被级别过滤或被中间件丢弃
+func (w *Flog) resolve(r *Record) bool {
+	priority, filter, tag := w.level()
+
+	tp := r.Priority & severityMask
+	r.Priority = (priority & facilityMask) | tp
+	r.Tag = tag
+
+	rules := w.redacts.Load()
+	if w.ring != nil {
+		// 崩溃缓存包括被过滤的日志，脱敏一份副本
+		c := *r
+		if rules != nil {
+			redact(*rules, &c)
+		}
+		w.ring.put(w.format(nil, &c))
+	}
+
+	if r.named != nil {
+		filter = r.named.filter(filter)
+	}
+	if filter < tp {
+		return false
+	}
+
+	if mws := w.mws.Load(); mws != nil {
+		if !runMiddleware(*mws, r) {
+			return false
+		}
+	}
+
+	w.addCaller(r)
+
+	// 在中间件和调用位置之后，它们添加的内容也会脱敏
+	if rules != nil {
+		redact(*rules, r)
 	}
+	return true
+}
+
+// stop 通知后台goroutine退出
+func (w *Flog) stop() {
+	w.stopOnce.Do(func() {
+		close(w.done)
+	})
+}
 
-	t1 := time.Now().Format(time.Stamp)
+func (w *Flog) write(r *Record) (int, error) {
+	n := len(r.Msg)
 
-	_, err := fmt.Fprintf(w.w, "<%d>%s %s[%d]: %s%s", p, t1, w.tag, os.Getpid(), msg, nl)
+	bp := getBuffer()
+	*bp = w.format(*bp, r)
+	err := w.writeRecord(r, *bp)
+	putBuffer(bp)
 	if err != nil {
 		return 0, err
 	}
 
-	return len(msg), nil
+	return n, nil
+}
+
+// writeRecord 写出r格式化后的line并记录统计，调用时必须持有mu
+func (w *Flog) writeRecord(r *Record, line []byte) error {
+	if err := w.emit(line); err != nil {
+		w.countErr(r.Priority)
+		return err
+	}
+	w.stats.Written[r.Priority&severityMask]++
+	if w.recorder != nil {
+		w.recorder.add(r)
+	}
+
+	return w.flushOn(r.Priority)
+}
+
+// emit 把格式化好的一行写入输出，调用时必须持有mu
+func (w *Flog) emit(b []byte) error {
+	if w.closed {
+		return ErrClosed
+	}
+	w.dirty = true
+
+	if w.delta {
+		b = w.appendDelta(b)
+	}
+
+	// 在加上时间前缀之后截断，前缀也计入长度限制
+	if w.maxLine > 0 {
+		var n int
+		b, n = truncateLine(b, w.maxLine)
+		w.stats.TruncatedBytes += uint64(n)
+	}
+
+	n, err := w.w.Write(b)
+	w.stats.BytesWritten += uint64(n)
+	return err
+}
+
+// countErr 记录一条写入失败的日志，调用时必须持有mu
+func (w *Flog) countErr(p Priority) {
+	w.stats.WriteErrors++
+	w.stats.Dropped[p&severityMask]++
+}
+
+// format 补全r的时间、pid等后格式化，tag由resolve填入
+func (w *Flog) format(b []byte, r *Record) []byte {
+	if r.Time.IsZero() {
+		r.Time = w.now()
+	}
+	if tf := w.timeFmt.Load(); tf != nil {
+		if tf.loc != nil {
+			r.Time = r.Time.In(tf.loc)
+		}
+		r.TimeFormat = tf.layout
+	}
+	r.Pid = pid
+
+	if w.termSafe.Load() {
+		r.Msg = terminalSafe(r.Msg)
+	}
+
+	if p := w.sd.Load(); p != nil {
+		sd := *p
+		if rules := w.redacts.Load(); rules != nil {
+			sd = redactSD(*rules, sd)
+		}
+		r.SD = mergeSD(sd, r.SD)
+	}
+
+	return w.callFormatter(w.getFormatter(), b, r)
 }
 
-func log_level(level string) Priority {
+// log_level 解析"facility:severity"，省略的部分为LOCAL0和INFO
+func log_level(level string) (Priority, error) {
 	level = strings.ToUpper(level)
 	sp := strings.SplitN(level, ":", 2)
 	if len(sp) < 2 {
@@ -267,7 +686,7 @@ func log_level(level string) Priority {
 	case "LOCAL5" : out = LOG_LOCAL5
 	case "LOCAL6" : out = LOG_LOCAL6
 	case "LOCAL7" : out = LOG_LOCAL7
-	default: return 0
+	default: return 0, fmt.Errorf("flog: unknown facility %q", sp[0])
 	}
 
 	switch sp[1] {
@@ -280,9 +699,9 @@ func log_level(level string) Priority {
 	case "" : fallthrough
 	case "INFO" : out |= LOG_INFO
 	case "DEBUG" : out |= LOG_DEBUG
-	default: return 0
+	default: return 0, fmt.Errorf("flog: unknown severity %q", sp[1])
 	}
 
-	return out
+	return out, nil
 }
 
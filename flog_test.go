@@ -1,9 +1,44 @@
 package flog
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	return c.t
+}
+
+func (c *fakeClock) add(d time.Duration) {
+	c.t = c.t.Add(d)
+}
+
+func newTestFlog(buf *bytes.Buffer, priority Priority, tag string) *Flog {
+	l := newFlog(nopCloser{buf}, priority, tag)
+	l.noclose = true
+	return l
+}
+
 func Test_log(t *testing.T) {
 	l, err := New("", "local0:Notice", "test")
 	if err != nil {
@@ -39,3 +74,271 @@ func Test_log2(t *testing.T) {
 	l.Notice("Notice 这个应该显示")
 	l.Notice("Warning 这个应该显示")
 }
+
+func Test_close(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "close.log")
+
+	l, err := File(file, LOG_LOCAL0|LOG_INFO, "test")
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+
+	if err := l.Info("before close"); err != nil {
+		t.Errorf("Expect:nil, get:%v", err)
+	}
+
+	if err := l.Close(); err != nil {
+		t.Errorf("Expect:nil, get:%v", err)
+	}
+	if err := l.Close(); err != nil {
+		t.Errorf("Expect: second Close nil, get:%v", err)
+	}
+
+	if err := l.Info("after close"); err != ErrClosed {
+		t.Errorf("Expect:%v, get:%v", ErrClosed, err)
+	}
+
+	if _, err := l.w.Write([]byte("x")); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Expect: file closed, get:%v", err)
+	}
+
+	data, _ := os.ReadFile(file)
+	if !strings.Contains(string(data), "before close") || strings.Contains(string(data), "after close") {
+		t.Errorf("Expect: only line before close, get:%q", data)
+	}
+}
+
+func Test_closeStderr(t *testing.T) {
+	l, err := New("<stderr>", "", "test")
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+
+	if err := l.Close(); err != nil {
+		t.Errorf("Expect:nil, get:%v", err)
+	}
+	if _, err := os.Stderr.Stat(); err != nil {
+		t.Errorf("Expect: stderr still open, get:%v", err)
+	}
+}
+
+func Test_newTarget(t *testing.T) {
+	for name, out := range map[string]*os.File{"": os.Stderr, "<stderr>": os.Stderr, "<stdout>": os.Stdout} {
+		l, err := New(name, "", "test")
+		if err != nil {
+			t.Fatalf("Expect:nil, get:%v", err)
+		}
+		if l.(*Flog).w != out {
+			t.Errorf("%q Expect:%v, get:%v", name, out.Name(), l.(*Flog).w)
+		}
+	}
+
+	file := filepath.Join(t.TempDir(), "app.log")
+	l, err := New(file, "", "test")
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	defer l.Close()
+	if l.(*Flog).file == nil {
+		t.Errorf("Expect: %s opened as file", file)
+	}
+}
+
+func Test_setLevel(t *testing.T) {
+	var buf syncBuffer
+	l := newFlog(nopCloser{&buf}, LOG_LOCAL0|LOG_NOTICE, "test")
+	l.noclose = true
+	l.SetFormatter(CompactFormatter{})
+
+	if err := l.SetLevel("bad:level"); err == nil {
+		t.Errorf("Expect: error")
+	}
+
+	if err := l.SetLevel("daemon:debug"); err != nil {
+		t.Errorf("Expect:nil, get:%v", err)
+	}
+	if p := l.getPriority(); p != LOG_DAEMON|LOG_DEBUG {
+		t.Errorf("Expect:%v, get:%v", LOG_DAEMON|LOG_DEBUG, p)
+	}
+	l.Debug("debug")
+	if s := buf.String(); s != "7|debug\n" {
+		t.Errorf("Expect:%q, get:%q", "7|debug\n", s)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			if i%2 == 0 {
+				l.SetLevel("local0:debug")
+			} else {
+				l.SetLevel("local1:err")
+			}
+			l.SetTag("test")
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				l.Err("err")
+				l.Debugf("debug %d", i)
+				l.Write([]byte("write"))
+			}
+		}()
+	}
+	wg.Wait()
+	<-done
+}
+
+type closeRecorder struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func Test_newFromWriter(t *testing.T) {
+	if _, err := NewFromWriter(nil, LOG_LOCAL0|LOG_INFO, LOG_INFO, "test"); err == nil {
+		t.Errorf("Expect: error for nil writer")
+	}
+
+	var buf closeRecorder
+	l, err := NewFromWriter(&buf, LOG_LOCAL0|LOG_INFO, LOG_NOTICE, "test", WithFormat(FormatSyslog))
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+
+	l.now = (&fakeClock{t: time.Date(2020, 3, 4, 5, 6, 7, 0, time.UTC)}).now
+	l.Info("filtered")
+	l.Notice("hello")
+
+	expect := fmt.Sprintf("<133>Mar  4 05:06:07 test[%d]: hello\n", os.Getpid())
+	if buf.String() != expect {
+		t.Errorf("Expect:%q, get:%q", expect, buf.String())
+	}
+
+	l.Close()
+	if !buf.closed {
+		t.Errorf("Expect: writer closed")
+	}
+}
+
+func Test_init(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "init.log")
+
+	l := new(Flog).Init(file, nil, LOG_LOCAL0|LOG_INFO, LOG_INFO, "test")
+	if l == nil {
+		t.Fatalf("Expect: file opened")
+	}
+	l.SetFormatter(CompactFormatter{})
+	l.Info("init")
+	l.Close()
+
+	if s := readFile(t, file); s != "6|init\n" {
+		t.Errorf("Expect:%q, get:%q", "6|init\n", s)
+	}
+
+	if l := new(Flog).Init("", os.Stderr, LOG_LOCAL0|LOG_INFO, LOG_INFO, "test"); !l.noclose {
+		t.Errorf("Expect: stderr not closed")
+	}
+}
+
+func Test_logLevel(t *testing.T) {
+	tests := []struct {
+		in     string
+		expect Priority
+		err    string
+	}{
+		{"kern:emerg", LOG_KERN | LOG_EMERG, ""},
+		{"", LOG_LOCAL0 | LOG_INFO, ""},
+		{"debug", LOG_LOCAL0 | LOG_DEBUG, ""},
+		{"daemon:", LOG_DAEMON | LOG_INFO, ""},
+		{":err", LOG_LOCAL0 | LOG_ERR, ""},
+		{"garbage:info", 0, `flog: unknown facility "GARBAGE"`},
+		{"user:nope", 0, `flog: unknown severity "NOPE"`},
+		{"DEBG", 0, `flog: unknown severity "DEBG"`},
+	}
+
+	for _, tt := range tests {
+		p, err := log_level(tt.in)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("%q Expect:%s, get:%v", tt.in, tt.err, err)
+			}
+			continue
+		}
+		if err != nil || p != tt.expect {
+			t.Errorf("%q Expect:%v, get:%v %v", tt.in, tt.expect, p, err)
+		}
+	}
+
+	if _, err := New("", "kern:emerg", "test"); err != nil {
+		t.Errorf("Expect:nil, get:%v", err)
+	}
+	if _, err := New("", "user:nope", "test"); err == nil || !strings.Contains(err.Error(), `"NOPE"`) {
+		t.Errorf("Expect: unknown severity, get:%v", err)
+	}
+}
+
+type countCloser struct {
+	syncBuffer
+	mu     sync.Mutex
+	closed int
+}
+
+func (c *countCloser) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed++
+	return nil
+}
+
+func Test_closeConcurrent(t *testing.T) {
+	var out countCloser
+	l := newFlog(&out, LOG_LOCAL0|LOG_INFO, "test")
+	l.SetFormatter(CompactFormatter{})
+	l.SetCoalesce(time.Hour)
+
+	l.Info("pending")
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				if err := l.Info("x"); err != nil && err != ErrClosed {
+					t.Errorf("Expect: nil or ErrClosed, get:%v", err)
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if err := l.Close(); err != nil {
+				t.Errorf("Expect:nil, get:%v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if out.closed != 1 {
+		t.Errorf("Expect: closed once, get:%d", out.closed)
+	}
+	if !strings.HasPrefix(out.String(), "6|pending\n") {
+		t.Errorf("Expect: pending line flushed, get:%q", out.String())
+	}
+	if err := l.Info("after"); err != ErrClosed {
+		t.Errorf("Expect:%v, get:%v", ErrClosed, err)
+	}
+
+	if err := new(Flog).Close(); err != nil {
+		t.Errorf("Expect:nil, get:%v", err)
+	}
+}
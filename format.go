@@ -0,0 +1,189 @@
+package flog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Record 是一条待格式化的日志
+type Record struct {
+	Priority Priority
+	Time     time.Time
+	Tag      string
+	Pid      int
+	Msg      string
+	Fields   []Field
+	SD       []SDElement
+
+	// TimeFormat 是SetTimeFormat设置的时间格式，为空时由Formatter决定
+	TimeFormat string
+
+	// pc 是slog记录的调用位置，见SetReportCaller
+	pc uintptr
+
+	// named 不为nil时使用它的过滤级别
+	named *Named
+}
+
+// timeLayout 返回r.TimeFormat，为空时返回def
+func (r *Record) timeLayout(def string) string {
+	if r.TimeFormat != "" {
+		return r.TimeFormat
+	}
+	return def
+}
+
+// Formatter 把一条日志追加到b中并返回，结果必须以换行结尾
+type Formatter interface {
+	Format(b []byte, r *Record) []byte
+}
+
+type formatterBox struct {
+	f Formatter
+}
+
+// callFormatter 捕获Formatter(或字段的String方法)中的panic，
+// 此时丢弃字段，以默认格式输出消息并附上panic信息
+func (w *Flog) callFormatter(f Formatter, b []byte, r *Record) (out []byte) {
+	n := len(b)
+
+	defer func() {
+		if e := recover(); e != nil {
+			w.fmtPanics.Add(1)
+
+			fr := *r
+			fr.Fields = nil
+			fr.Msg = strings.TrimSuffix(r.Msg, "\n") + " (formatter panic: " + fmt.Sprint(e) + ")"
+			out = HumanFormatter{}.Format(b[:n], &fr)
+		}
+	}()
+
+	return f.Format(b, r)
+}
+
+// SyslogFormatter 输出 "<pri>timestamp tag[pid]: msg"
+type SyslogFormatter struct{}
+
+func (SyslogFormatter) Format(b []byte, r *Record) []byte {
+	b = append(b, '<')
+	b = strconv.AppendInt(b, int64(r.Priority), 10)
+	b = append(b, '>')
+	b = r.Time.AppendFormat(b, r.timeLayout(time.Stamp))
+	b = append(b, ' ')
+	b = append(b, r.Tag...)
+	b = append(b, '[')
+	b = strconv.AppendInt(b, int64(r.Pid), 10)
+	b = append(b, "]:"...)
+	return appendMsg(b, " ", r.Msg, r.Fields)
+}
+
+// HumanFormatter 是文件和终端的默认格式，不含<pri>：
+// "timestamp SEVERITY tag[pid]: msg"
+type HumanFormatter struct{}
+
+func (HumanFormatter) Format(b []byte, r *Record) []byte {
+	b = r.Time.AppendFormat(b, r.timeLayout(time.Stamp))
+	b = append(b, ' ')
+	b = append(b, severityLabels[r.Priority&severityMask]...)
+	b = append(b, ' ')
+	b = append(b, r.Tag...)
+	b = append(b, '[')
+	b = strconv.AppendInt(b, int64(r.Pid), 10)
+	b = append(b, "]:"...)
+	return appendMsg(b, " ", r.Msg, r.Fields)
+}
+
+// CompactFormatter 只输出一位数字的级别和消息，如 "3|disk error"，
+// 用于带宽受限的场合，不兼容syslog。Delim为空时使用"|"
+type CompactFormatter struct {
+	Delim string
+}
+
+func (f CompactFormatter) Format(b []byte, r *Record) []byte {
+	delim := f.Delim
+	if delim == "" {
+		delim = "|"
+	}
+
+	b = append(b, '0'+byte(r.Priority&severityMask))
+	return appendMsg(b, delim, r.Msg, r.Fields)
+}
+
+// Format 是内置的输出格式
+type Format int
+
+const (
+	FormatHuman Format = iota
+	FormatSyslog
+	FormatCompact
+	FormatJSON
+	FormatRFC5424
+	FormatConsole
+)
+
+// Formatter 返回该格式对应的Formatter，未知的格式返回HumanFormatter
+func (f Format) Formatter() Formatter {
+	switch f {
+	case FormatSyslog:
+		return SyslogFormatter{}
+	case FormatCompact:
+		return CompactFormatter{}
+	case FormatJSON:
+		return NewJSONFormatter()
+	case FormatRFC5424:
+		return NewRFC5424Formatter()
+	case FormatConsole:
+		return ConsoleFormatter{}
+	}
+	return HumanFormatter{}
+}
+
+func formatterName(f Formatter) string {
+	switch f.(type) {
+	case SyslogFormatter:
+		return "syslog"
+	case HumanFormatter:
+		return "human"
+	case CompactFormatter:
+		return "compact"
+	case *JSONFormatter:
+		return "json"
+	case *RFC5424Formatter:
+		return "rfc5424"
+	case ConsoleFormatter:
+		return "console"
+	case JournalFormatter:
+		return "journal"
+	}
+	return "custom"
+}
+
+// appendMsg 追加分隔符、消息、字段和换行：
+// 空消息只追加换行(不留分隔符)；只含空白的消息原样保留；
+// 已经以换行结尾的消息不再追加换行，字段放在这个换行之前
+func appendMsg(b []byte, sep, msg string, fields []Field) []byte {
+	if msg == "" && len(fields) == 0 {
+		return append(b, '\n')
+	}
+
+	nl := strings.HasSuffix(msg, "\n")
+	if nl && len(fields) > 0 {
+		msg = msg[:len(msg)-1]
+	}
+
+	b = append(b, sep...)
+	b = append(b, msg...)
+	for i, f := range fields {
+		if i > 0 || msg != "" {
+			b = append(b, ' ')
+		}
+		b = appendField(b, f)
+	}
+
+	if !nl || len(fields) > 0 {
+		b = append(b, '\n')
+	}
+	return b
+}
@@ -0,0 +1,266 @@
+package flog
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_defaultFormat(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "default.log")
+
+	f, err := File(file, LOG_LOCAL0|LOG_INFO, "test")
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	f.Info("same message")
+
+	data, _ := os.ReadFile(file)
+	ok, _ := regexp.Match(`^\w{3} [ \d]\d \d\d:\d\d:\d\d INFO test\[\d+\]: same message\n$`, data)
+	if !ok {
+		t.Errorf("Expect: human format, get:%q", data)
+	}
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer conn.Close()
+
+	s, err := Dial("udp", conn.LocalAddr().String(), LOG_LOCAL0|LOG_INFO, "test")
+	if err == ErrNotSupported {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	defer s.Close()
+	s.Info("same message")
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	buf := make([]byte, 1024)
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+
+	msg := string(buf[:n])
+	if !strings.HasPrefix(msg, "<134>") || !strings.Contains(msg, "test[") || !strings.Contains(msg, "same message") {
+		t.Errorf("Expect: syslog format, get:%q", msg)
+	}
+}
+
+func Test_dialError(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	// 失败时返回nil接口，而不是装着nil指针的Writer
+	w, err := Dial("tcp", addr, LOG_LOCAL0|LOG_INFO, "test")
+	if err == nil || w != nil {
+		t.Errorf("Expect: nil Writer and error, get:%v %v", w, err)
+	}
+}
+
+func Test_trailingNewline(t *testing.T) {
+	tests := []struct {
+		msg     string
+		syslog  string
+		compact string
+	}{
+		{"", "test[1]:\n", "6\n"},
+		{" ", "test[1]:  \n", "6| \n"},
+		{"x", "test[1]: x\n", "6|x\n"},
+		{"x\n", "test[1]: x\n", "6|x\n"},
+		{"x\n\n", "test[1]: x\n\n", "6|x\n\n"},
+	}
+
+	for _, tt := range tests {
+		r := Record{
+			Priority: LOG_LOCAL0 | LOG_INFO,
+			Time:     time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+			Tag:      "test",
+			Pid:      1,
+			Msg:      tt.msg,
+		}
+
+		s := string(SyslogFormatter{}.Format(nil, &r))
+		expect := "<134>Jan  2 03:04:05 " + tt.syslog
+		if s != expect {
+			t.Errorf("msg %q Expect:%q, get:%q", tt.msg, expect, s)
+		}
+
+		s = string(CompactFormatter{}.Format(nil, &r))
+		if s != tt.compact {
+			t.Errorf("msg %q Expect:%q, get:%q", tt.msg, tt.compact, s)
+		}
+	}
+}
+
+func Test_compactFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestFlog(&buf, LOG_LOCAL0|LOG_DEBUG, "test")
+	l.SetFormatter(CompactFormatter{})
+
+	l.Emerg("a")
+	l.Alert("b")
+	l.Crit("c")
+	l.Err("disk error")
+	l.Warning("e")
+	l.Notice("f")
+	l.Info("g")
+	l.Debug("h")
+
+	expect := "0|a\n1|b\n2|c\n3|disk error\n4|e\n5|f\n6|g\n7|h\n"
+	if buf.String() != expect {
+		t.Errorf("Expect:%q, get:%q", expect, buf.String())
+	}
+
+	buf.Reset()
+	l.SetFormatter(CompactFormatter{Delim: " "})
+	l.Err("disk error")
+
+	if buf.String() != "3 disk error\n" {
+		t.Errorf("Expect:%q, get:%q", "3 disk error\n", buf.String())
+	}
+}
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func Test_setFormatterConcurrent(t *testing.T) {
+	var buf syncBuffer
+	l := newFlog(nopCloser{&buf}, LOG_LOCAL0|LOG_INFO, "test")
+	l.SetFormatter(CompactFormatter{})
+
+	stop := make(chan struct{})
+	swapped := make(chan struct{})
+	go func() {
+		defer close(swapped)
+		formatters := []Formatter{CompactFormatter{}, NewJSONFormatter(), SyslogFormatter{}}
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			l.SetFormatter(formatters[i%len(formatters)])
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				l.Info("swap")
+			}
+		}()
+	}
+	wg.Wait()
+	close(stop)
+	<-swapped
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 8*500 {
+		t.Fatalf("Expect:%d lines, get:%d", 8*500, len(lines))
+	}
+
+	for _, line := range lines {
+		switch {
+		case line == "6|swap":
+		case strings.HasPrefix(line, "{") && strings.HasSuffix(line, `"msg":"swap"}`):
+		case strings.HasPrefix(line, "<134>") && strings.HasSuffix(line, "]: swap"):
+		default:
+			t.Errorf("mixed format line %q", line)
+		}
+	}
+}
+
+type panicFormatter struct{}
+
+func (panicFormatter) Format(b []byte, r *Record) []byte {
+	panic("bad formatter")
+}
+
+type panicStringer struct{}
+
+func (panicStringer) String() string {
+	panic("bad stringer")
+}
+
+func Test_formatterPanic(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestFlog(&buf, LOG_LOCAL0|LOG_INFO, "test")
+	l.SetFormatter(panicFormatter{})
+
+	if err := l.Info("survive"); err != nil {
+		t.Errorf("Expect:nil, get:%v", err)
+	}
+	if !strings.HasSuffix(buf.String(), " INFO test["+strconv.Itoa(os.Getpid())+"]: survive (formatter panic: bad formatter)\n") {
+		t.Errorf("Expect: fallback line, get:%q", buf.String())
+	}
+
+	buf.Reset()
+	l.SetFormatter(NewJSONFormatter())
+	l.log(LOG_INFO, "field", []Field{{"v", panicStringer{}}})
+	if !strings.Contains(buf.String(), "field (formatter panic: bad stringer)") {
+		t.Errorf("Expect: fallback line, get:%q", buf.String())
+	}
+
+	if n := l.Stats().FormatterPanics; n != 2 {
+		t.Errorf("Expect:2, get:%d", n)
+	}
+}
+
+func Test_setFormat(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestFlog(&buf, LOG_LOCAL0|LOG_INFO, "test")
+	l.now = (&fakeClock{t: time.Date(2020, 3, 4, 5, 6, 7, 0, time.UTC)}).now
+
+	l.SetFormat(FormatJSON)
+	l.Info("json")
+	expect := fmt.Sprintf(`{"time":"2020-03-04T05:06:07Z","severity":"info","facility":"local0","tag":"test","pid":%d,"msg":"json"}`+"\n", os.Getpid())
+	if buf.String() != expect {
+		t.Errorf("Expect:%q, get:%q", expect, buf.String())
+	}
+
+	buf.Reset()
+	l.SetFormat(FormatCompact)
+	l.Info("compact")
+	if s := buf.String(); s != "6|compact\n" {
+		t.Errorf("Expect:%q, get:%q", "6|compact\n", s)
+	}
+
+	for f, name := range map[Format]string{FormatHuman: "human", FormatSyslog: "syslog", FormatRFC5424: "rfc5424", Format(99): "human"} {
+		if s := formatterName(f.Formatter()); s != name {
+			t.Errorf("Expect:%s, get:%s", name, s)
+		}
+	}
+}
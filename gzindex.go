@@ -0,0 +1,291 @@
+package flog
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 每个gzip member包含的未压缩字节数(在行边界切分)
+var gzindexBlockSize = 64 * 1024
+
+type gzindexEntry struct {
+	offset      int64
+	first, last time.Time
+}
+
+// CompressIndexed 把日志文件src压缩为src.gz，并生成索引src.gz.idx。
+// .gz由多个gzip member组成，可以用普通的gzip工具解压；
+// 索引记录每个member的偏移和其中日志的时间范围，供OpenIndexed直接跳到某个时间附近。
+// 两个文件都先写临时文件再改名，中途失败不会留下不完整的.gz
+func CompressIndexed(src string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp, idx, err := gzipIndexedToTemp(in, src)
+	if err != nil {
+		return err
+	}
+
+	dst := src + ".gz"
+	if err := writeGzindex(dst+".idx", idx); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		os.Remove(tmp)
+		os.Remove(dst + ".idx")
+		return err
+	}
+	return nil
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// writeIndexedGzip 压缩in并返回索引，ref用于补全日志中缺少的年份或日期，
+// 一般是文件的修改时间
+func writeIndexedGzip(out io.Writer, in io.Reader, ref time.Time) ([]gzindexEntry, error) {
+	cw := &countWriter{w: out}
+	br := bufio.NewReader(in)
+
+	var idx []gzindexEntry
+	var zw *gzip.Writer
+	var cur gzindexEntry
+	size := 0
+
+	finish := func() error {
+		if zw == nil {
+			return nil
+		}
+		err := zw.Close()
+		zw = nil
+		idx = append(idx, cur)
+		return err
+	}
+
+	for {
+		line, err := br.ReadString('\n')
+		if len(line) > 0 {
+			if zw == nil {
+				zw = gzip.NewWriter(cw)
+				cur = gzindexEntry{offset: cw.n}
+				size = 0
+			}
+
+			if t, ok := lineTime(strings.TrimSuffix(line, "\n"), ref); ok {
+				if cur.first.IsZero() {
+					cur.first = t
+				}
+				cur.last = t
+			}
+
+			if _, werr := io.WriteString(zw, line); werr != nil {
+				return nil, werr
+			}
+			size += len(line)
+
+			if size >= gzindexBlockSize {
+				if ferr := finish(); ferr != nil {
+					return nil, ferr
+				}
+			}
+		}
+
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := finish(); err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+// lineTime 解析一行日志开头的时间，支持Human/Syslog(各种Stamp、RFC3339、DateTime)、
+// RFC5424、JSON和Console格式。缺少年份或日期时取不晚于ref的最近一个
+func lineTime(line string, ref time.Time) (time.Time, bool) {
+	if v, ok := strings.CutPrefix(line, `{"time":"`); ok {
+		if i := strings.IndexByte(v, '"'); i > 0 {
+			t, err := time.Parse(time.RFC3339Nano, v[:i])
+			return t, err == nil
+		}
+		return time.Time{}, false
+	}
+
+	// <pri>，RFC5424在其后还有版本号
+	if strings.HasPrefix(line, "<") {
+		if i := strings.IndexByte(line, '>'); i > 0 {
+			line = line[i+1:]
+			line = strings.TrimPrefix(line, "1 ")
+		}
+	}
+
+	token, _, _ := strings.Cut(line, " ")
+	if t, err := time.Parse(time.RFC3339Nano, token); err == nil {
+		return t, true
+	}
+	if t, ok := prefixTime(line, time.DateTime, ref.Location()); ok {
+		return t, true
+	}
+	for _, layout := range []string{time.StampMicro, time.StampMilli, time.Stamp} {
+		if t, ok := prefixTime(line, layout, ref.Location()); ok {
+			return fillYear(t, ref), true
+		}
+	}
+	if t, ok := prefixTime(line, "15:04:05.000", ref.Location()); ok {
+		y, m, d := ref.Date()
+		t = time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), ref.Location())
+		if t.After(ref) {
+			t = t.AddDate(0, 0, -1)
+		}
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// prefixTime 按定长的layout解析line开头，其后必须是空格或行尾
+func prefixTime(line, layout string, loc *time.Location) (time.Time, bool) {
+	n := len(layout)
+	if len(line) < n || (len(line) > n && line[n] != ' ') {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation(layout, line[:n], loc)
+	return t, err == nil
+}
+
+// fillYear 为没有年份的t补上年份，取不晚于ref的最近一年(留一天余量应对时钟偏差)
+func fillYear(t, ref time.Time) time.Time {
+	t = time.Date(ref.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), ref.Location())
+	if t.After(ref.Add(24 * time.Hour)) {
+		t = t.AddDate(-1, 0, 0)
+	}
+	return t
+}
+
+// writeGzindex 写入索引，先写临时文件再改名
+func writeGzindex(path string, idx []gzindexEntry) error {
+	var b strings.Builder
+	for _, e := range idx {
+		fmt.Fprintf(&b, "%d\t%s\t%s\n", e.offset, e.first.Format(time.RFC3339Nano), e.last.Format(time.RFC3339Nano))
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteString(b.String())
+	if err1 := f.Close(); err == nil {
+		err = err1
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+func readGzindex(path string) ([]gzindexEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var idx []gzindexEntry
+	for _, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		f := strings.Split(line, "\t")
+		if len(f) != 3 {
+			return nil, errors.New("flog: bad index line " + strconv.Quote(line))
+		}
+
+		var e gzindexEntry
+		if e.offset, err = strconv.ParseInt(f[0], 10, 64); err != nil {
+			return nil, err
+		}
+		if e.first, err = time.Parse(time.RFC3339Nano, f[1]); err != nil {
+			return nil, err
+		}
+		if e.last, err = time.Parse(time.RFC3339Nano, f[2]); err != nil {
+			return nil, err
+		}
+		idx = append(idx, e)
+	}
+	return idx, nil
+}
+
+// seekGzindex 返回第一个可能包含from之后日志的member的偏移
+func seekGzindex(idx []gzindexEntry, from time.Time) int64 {
+	for _, e := range idx {
+		if !e.last.IsZero() && !e.last.Before(from) {
+			return e.offset
+		}
+	}
+	if len(idx) > 0 {
+		return idx[len(idx)-1].offset
+	}
+	return 0
+}
+
+type gzindexReader struct {
+	*gzip.Reader
+	f *os.File
+}
+
+func (r *gzindexReader) Close() error {
+	r.Reader.Close()
+	return r.f.Close()
+}
+
+// OpenIndexed 打开CompressIndexed生成的path(.gz文件)，
+// 借助索引从包含from的member开始解压，跳过之前的部分。
+// 返回的内容从该member开头开始，可能包含少量早于from的日志
+func OpenIndexed(path string, from time.Time) (io.ReadCloser, error) {
+	idx, err := readGzindex(path + ".idx")
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := f.Seek(seekGzindex(idx, from), io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	zr, err := gzip.NewReader(bufio.NewReader(f))
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &gzindexReader{zr, f}, nil
+}
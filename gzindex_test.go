@@ -0,0 +1,179 @@
+package flog
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_compressIndexed(t *testing.T) {
+	old := gzindexBlockSize
+	gzindexBlockSize = 1024
+	defer func() { gzindexBlockSize = old }()
+
+	file := filepath.Join(t.TempDir(), "app.log")
+	l, err := File(file, LOG_LOCAL0|LOG_INFO, "app", WithDatasync(time.Hour))
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	defer l.Close()
+
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+	c := &fakeClock{t: start}
+	l.now = c.now
+
+	const n = 1000
+	for i := 0; i < n; i++ {
+		l.Info("line " + strconv.Itoa(i))
+		c.add(time.Second)
+	}
+
+	if err := CompressIndexed(file); err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+
+	// 整个文件仍是合法的gzip
+	f, _ := os.Open(file + ".gz")
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	all, _ := io.ReadAll(zr)
+	f.Close()
+	if strings.Count(string(all), "\n") != n {
+		t.Fatalf("Expect:%d lines, get:%d", n, strings.Count(string(all), "\n"))
+	}
+
+	idx, err := readGzindex(file + ".gz.idx")
+	if err != nil || len(idx) < 10 {
+		t.Fatalf("Expect: many blocks, get:%d %v", len(idx), err)
+	}
+
+	target := start.Add(n / 2 * time.Second)
+	if off := seekGzindex(idx, target); off <= 0 {
+		t.Errorf("Expect: seek past start, get:%d", off)
+	}
+
+	r, err := OpenIndexed(file+".gz", target)
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	defer r.Close()
+
+	s := NewScanner(r)
+	var first Record
+	count := 0
+	found := false
+	for s.Scan() {
+		if count == 0 {
+			first = s.Record()
+			first.Time = fillYear(first.Time, time.Now())
+		}
+		if fillYear(s.Record().Time, time.Now()).Equal(target) {
+			found = true
+		}
+		count++
+	}
+
+	if first.Time.After(target) || !found {
+		t.Errorf("Expect: block containing target, first:%v found:%v", first.Time, found)
+	}
+	if count >= n*2/3 {
+		t.Errorf("Expect: skipped earlier blocks, read:%d", count)
+	}
+}
+
+func Test_rotateCompressIndexed(t *testing.T) {
+	old := gzindexBlockSize
+	gzindexBlockSize = 1024
+	defer func() { gzindexBlockSize = old }()
+
+	file := filepath.Join(t.TempDir(), "app.log")
+	l, err := File(file, LOG_LOCAL0|LOG_INFO, "app", WithMaxSize(1<<20), WithMaxBackups(3), WithCompressIndexed())
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+	c := &fakeClock{t: start}
+	l.now = c.now
+
+	rotate := func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if err := l.file.rotate(); err != nil {
+			t.Fatalf("Expect:nil, get:%v", err)
+		}
+	}
+
+	const n = 1000
+	for i := 0; i < n; i++ {
+		l.Info("line " + strconv.Itoa(i))
+		c.add(time.Second)
+	}
+	rotate()
+	l.file.waitCompress()
+
+	// 再轮转一次，索引随备份后移
+	l.Info("newer")
+	rotate()
+	l.Close()
+
+	if !exists(file+".1.gz.idx") || exists(file+".2") || exists(file+".1") {
+		t.Errorf("Expect: .1.gz.idx and no uncompressed backups")
+	}
+
+	target := start.Add(n / 2 * time.Second)
+	r, err := OpenIndexed(file+".2.gz", target)
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	defer r.Close()
+
+	s := NewScanner(r)
+	count := 0
+	var first Record
+	for s.Scan() {
+		if count == 0 {
+			first = s.Record()
+			first.Time = fillYear(first.Time, time.Now())
+		}
+		count++
+	}
+	if first.Time.After(target) || first.Time.Before(start.Add(n/3*time.Second)) {
+		t.Errorf("Expect: seek near %v, get:%v", target, first.Time)
+	}
+	if count >= n*2/3 {
+		t.Errorf("Expect: skipped earlier blocks, read:%d", count)
+	}
+}
+
+func Test_lineTime(t *testing.T) {
+	ref := time.Date(2024, 1, 1, 0, 30, 0, 0, time.UTC)
+	want := time.Date(2023, 12, 31, 23, 59, 58, 0, time.UTC)
+
+	lines := []string{
+		"Dec 31 23:59:58 INFO app[1]: msg",
+		"<134>Dec 31 23:59:58 app[1]: msg",
+		"Dec 31 23:59:58.000 INFO app[1]: msg",
+		"2023-12-31T23:59:58Z INFO app[1]: msg",
+		"2023-12-31 23:59:58 INFO app[1]: msg",
+		"<134>1 2023-12-31T23:59:58Z host app 1 - - msg",
+		`{"time":"2023-12-31T23:59:58Z","severity":"info","msg":"msg"}`,
+		"23:59:58.000 INFO    app      msg",
+	}
+	for _, line := range lines {
+		if got, ok := lineTime(line, ref); !ok || !got.Equal(want) {
+			t.Errorf("Expect:%v, get:%v %v (%s)", want, got, ok, line)
+		}
+	}
+
+	if _, ok := lineTime("garbage", ref); ok {
+		t.Errorf("Expect: no time for garbage")
+	}
+}
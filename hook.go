@@ -0,0 +1,38 @@
+package flog
+
+import "time"
+
+// Hook 在日志写出后被调用，可用于统计、告警或在写入失败时另存日志。
+// 写入失败时同样会调用。Fire返回的错误只计入Stats().HookErrors，不影响日志本身
+type Hook interface {
+	Fire(severity Priority, msg string, t time.Time) error
+}
+
+// HookFunc 把普通函数转换为Hook
+type HookFunc func(severity Priority, msg string, t time.Time) error
+
+func (f HookFunc) Fire(severity Priority, msg string, t time.Time) error {
+	return f(severity, msg, t)
+}
+
+// AddHook 追加一个Hook，Hook按添加顺序在调用方goroutine中执行，
+// 执行时不持有日志的锁
+func (w *Flog) AddHook(h Hook) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var hooks []Hook
+	if old := w.hooks.Load(); old != nil {
+		hooks = append(hooks, *old...)
+	}
+	hooks = append(hooks, h)
+	w.hooks.Store(&hooks)
+}
+
+func (w *Flog) fire(hooks []Hook, r *Record) {
+	for _, h := range hooks {
+		if err := h.Fire(r.Priority&severityMask, r.Msg, r.Time); err != nil {
+			w.hookErrs.Add(1)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package flog
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_hook(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestFlog(&buf, LOG_LOCAL0|LOG_INFO, "test")
+	l.SetFormatter(CompactFormatter{})
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	l.now = (&fakeClock{t: now}).now
+
+	var crits []string
+	l.AddHook(HookFunc(func(p Priority, msg string, tm time.Time) error {
+		if p <= LOG_CRIT {
+			crits = append(crits, msg)
+		}
+		if !tm.Equal(now) {
+			t.Errorf("Expect:%v, get:%v", now, tm)
+		}
+		return nil
+	}))
+	counts := map[Priority]int{}
+	l.AddHook(HookFunc(func(p Priority, msg string, tm time.Time) error {
+		counts[p]++
+		return errors.New("hook failed")
+	}))
+
+	l.Crit("db down")
+	l.Info("ok")
+	l.Debug("filtered")
+
+	if len(crits) != 1 || crits[0] != "db down" {
+		t.Errorf("Expect:[db down], get:%v", crits)
+	}
+	if counts[LOG_CRIT] != 1 || counts[LOG_INFO] != 1 || counts[LOG_DEBUG] != 0 {
+		t.Errorf("Expect: crit 1 info 1 debug 0, get:%v", counts)
+	}
+	if n := l.Stats().HookErrors; n != 2 {
+		t.Errorf("Expect:2, get:%d", n)
+	}
+	if buf.String() != "2|db down\n6|ok\n" {
+		t.Errorf("Expect:%q, get:%q", "2|db down\n6|ok\n", buf.String())
+	}
+}
+
+func Test_hookWriteFailed(t *testing.T) {
+	l := newFlog(&failWriter{fail: true}, LOG_LOCAL0|LOG_INFO, "test")
+
+	var lost []string
+	l.AddHook(HookFunc(func(p Priority, msg string, tm time.Time) error {
+		lost = append(lost, msg)
+		return nil
+	}))
+
+	if err := l.Err("lost"); err == nil {
+		t.Errorf("Expect: write error")
+	}
+	if len(lost) != 1 || lost[0] != "lost" {
+		t.Errorf("Expect:[lost], get:%v", lost)
+	}
+}
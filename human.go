@@ -0,0 +1,96 @@
+package flog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Bytes 以二进制单位显示字节数，如 Bytes(1536) 显示为 "1.5KiB"
+type Bytes int64
+
+func (n Bytes) String() string {
+	const units = "KMGTPE"
+
+	v := int64(n)
+	sign := ""
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+
+	if v < 1024 {
+		return sign + strconv.FormatInt(v, 10) + "B"
+	}
+
+	f := float64(v)
+	i := -1
+	for f >= 1024 && i < len(units)-1 {
+		f /= 1024
+		i++
+	}
+
+	s := strconv.FormatFloat(f, 'f', 1, 64)
+	s = strings.TrimSuffix(s, ".0")
+	return sign + s + units[i:i+1] + "iB"
+}
+
+// Duration 以适合阅读的精度显示时长，如 Duration(90*time.Second) 显示为 "1m30s"
+type Duration time.Duration
+
+func (d Duration) String() string {
+	v := time.Duration(d)
+
+	a := v
+	if a < 0 {
+		a = -a
+	}
+
+	switch {
+	case a >= time.Minute:
+		v = v.Round(time.Second)
+	case a >= time.Second:
+		v = v.Round(time.Millisecond)
+	case a >= time.Millisecond:
+		v = v.Round(time.Microsecond)
+	}
+
+	return v.String()
+}
+
+// ParseBytes 解析 "100MB"、"1.5GiB"、"512K"、"1024" 形式的大小，
+// K、M、G、T 都按1024进位，KB与KiB相同
+func ParseBytes(s string) (Bytes, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(s)
+	}
+
+	f, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("flog: invalid size %q", s)
+	}
+
+	unit := strings.ToUpper(strings.TrimSpace(s[i:]))
+	unit = strings.TrimSuffix(strings.TrimSuffix(unit, "B"), "I")
+
+	mul := float64(1)
+	switch unit {
+	case "":
+	case "K":
+		mul = 1 << 10
+	case "M":
+		mul = 1 << 20
+	case "G":
+		mul = 1 << 30
+	case "T":
+		mul = 1 << 40
+	default:
+		return 0, fmt.Errorf("flog: invalid size %q", s)
+	}
+	return Bytes(f * mul), nil
+}
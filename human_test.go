@@ -0,0 +1,74 @@
+package flog
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func Test_bytes(t *testing.T) {
+	tests := []struct {
+		n      int64
+		expect string
+	}{
+		{0, "0B"},
+		{1023, "1023B"},
+		{1024, "1KiB"},
+		{1536, "1.5KiB"},
+		{10 * 1024 * 1024, "10MiB"},
+		{5 * 1024 * 1024 * 1024 / 4, "1.2GiB"},
+		{-2048, "-2KiB"},
+	}
+
+	for _, tt := range tests {
+		if s := Bytes(tt.n).String(); s != tt.expect {
+			t.Errorf("Bytes(%d) Expect:%s, get:%s", tt.n, tt.expect, s)
+		}
+	}
+}
+
+func Test_duration(t *testing.T) {
+	tests := []struct {
+		d      time.Duration
+		expect string
+	}{
+		{90000 * time.Millisecond, "1m30s"},
+		{time.Hour + 2*time.Minute + 3456*time.Millisecond, "1h2m3s"},
+		{1234567 * time.Microsecond, "1.235s"},
+		{1500 * time.Microsecond, "1.5ms"},
+		{250 * time.Nanosecond, "250ns"},
+	}
+
+	for _, tt := range tests {
+		if s := Duration(tt.d).String(); s != tt.expect {
+			t.Errorf("Duration(%v) Expect:%s, get:%s", tt.d, tt.expect, s)
+		}
+	}
+
+	s := fmt.Sprintf("took %v, read %v", Duration(90*time.Second), Bytes(1536))
+	if s != "took 1m30s, read 1.5KiB" {
+		t.Errorf("Expect:%q, get:%q", "took 1m30s, read 1.5KiB", s)
+	}
+}
+
+func Test_parseBytes(t *testing.T) {
+	tests := map[string]int64{
+		"1024":   1024,
+		"512K":   512 * 1024,
+		"100MB":  100 << 20,
+		"1.5GiB": 3 << 29,
+		"2 kb":   2048,
+	}
+	for in, expect := range tests {
+		n, err := ParseBytes(in)
+		if err != nil || int64(n) != expect {
+			t.Errorf("%q Expect:%d, get:%d %v", in, expect, n, err)
+		}
+	}
+
+	for _, in := range []string{"", "MB", "10XB", "-1"} {
+		if _, err := ParseBytes(in); err == nil {
+			t.Errorf("%q Expect: error", in)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package flog
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// randomID 是默认的ID生成器，返回16位十六进制随机串
+func randomID() string {
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// SetIDGenerator 设置生成关联ID(trace id等)的函数，nil恢复默认
+func (w *Flog) SetIDGenerator(f func() string) {
+	if f == nil {
+		w.idgen.Store(nil)
+		return
+	}
+	w.idgen.Store(&f)
+}
+
+// NewID 生成一个新的关联ID
+func (w *Flog) NewID() string {
+	if f := w.idgen.Load(); f != nil {
+		return (*f)()
+	}
+	return randomID()
+}
+
+// WithNewID 返回一个日志，其消息带有以key为名的新生成的ID字段
+func (w *Flog) WithNewID(key string) *Entry {
+	return (&Entry{l: w}).WithNewID(key)
+}
+
+func (e *Entry) WithNewID(key string) *Entry {
+	return e.with(Field{key, e.l.NewID()})
+}
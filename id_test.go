@@ -0,0 +1,57 @@
+package flog
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+)
+
+func Test_idGenerator(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestFlog(&buf, LOG_LOCAL0|LOG_INFO, "test")
+	l.SetFormatter(CompactFormatter{})
+
+	if id := l.NewID(); len(id) != 16 {
+		t.Errorf("Expect: 16 chars, get:%q", id)
+	}
+
+	n := 0
+	l.SetIDGenerator(func() string {
+		n++
+		return "req-" + strconv.Itoa(n)
+	})
+
+	l.WithNewID("trace_id").Info("first")
+	e := l.WithNewID("trace_id")
+	e.Info("second")
+	e.Info("again")
+
+	expect := "6|first trace_id=req-1\n6|second trace_id=req-2\n6|again trace_id=req-2\n"
+	if buf.String() != expect {
+		t.Errorf("Expect:%q, get:%q", expect, buf.String())
+	}
+}
+
+func Test_idGeneratorConcurrent(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestFlog(&buf, LOG_LOCAL0|LOG_INFO, "test")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			if i%2 == 0 {
+				l.SetIDGenerator(func() string { return "fixed" })
+			} else {
+				l.SetIDGenerator(nil)
+			}
+		}
+	}()
+
+	for i := 0; i < 200; i++ {
+		if id := l.NewID(); id != "fixed" && len(id) != 16 {
+			t.Errorf("Expect: fixed or random id, get:%q", id)
+		}
+	}
+	<-done
+}
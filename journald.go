@@ -0,0 +1,72 @@
+package flog
+
+import (
+	"encoding/binary"
+	"strconv"
+	"strings"
+)
+
+// journalSocket 是journald接收原生协议的socket
+var journalSocket = "/run/systemd/journal/socket"
+
+// JournalFormatter 把一条日志编码为journald原生协议的变量：
+// MESSAGE、PRIORITY、SYSLOG_FACILITY、SYSLOG_IDENTIFIER、SYSLOG_PID，
+// 字段作为同名的变量，名字转为大写，不能用作变量名的字符替换为_，
+// 见DialJournal
+type JournalFormatter struct{}
+
+func (JournalFormatter) Format(b []byte, r *Record) []byte {
+	b = appendJournal(b, "MESSAGE", strings.TrimSuffix(r.Msg, "\n"))
+	b = appendJournal(b, "PRIORITY", strconv.Itoa(int(r.Priority&severityMask)))
+	b = appendJournal(b, "SYSLOG_FACILITY", strconv.Itoa(int(r.Priority&facilityMask)>>3))
+	b = appendJournal(b, "SYSLOG_IDENTIFIER", r.Tag)
+	b = appendJournal(b, "SYSLOG_PID", strconv.Itoa(r.Pid))
+
+	for _, f := range r.Fields {
+		if k := journalKey(f.Key); k != "" {
+			b = appendJournal(b, k, fieldString(f.Value))
+		}
+	}
+	return b
+}
+
+// appendJournal 追加一个变量，含换行的值使用 "KEY\n" 加8字节小端长度的二进制格式
+func appendJournal(b []byte, k, v string) []byte {
+	b = append(b, k...)
+	if strings.IndexByte(v, '\n') < 0 {
+		b = append(b, '=')
+	} else {
+		b = append(b, '\n')
+		b = binary.LittleEndian.AppendUint64(b, uint64(len(v)))
+	}
+	b = append(b, v...)
+	return append(b, '\n')
+}
+
+// journalKey 返回journald可以接受的变量名：大写字母、数字和_，
+// 不以数字或_开头(_开头的由journald自己设置)，最长64个字符
+func journalKey(k string) string {
+	b := make([]byte, 0, len(k))
+	for i := 0; i < len(k); i++ {
+		c := k[i]
+		switch {
+		case 'a' <= c && c <= 'z':
+			c -= 'a' - 'A'
+		case 'A' <= c && c <= 'Z':
+		case '0' <= c && c <= '9', c == '_':
+			if len(b) == 0 {
+				continue
+			}
+		default:
+			if len(b) == 0 {
+				continue
+			}
+			c = '_'
+		}
+		b = append(b, c)
+	}
+	if len(b) > 64 {
+		b = b[:64]
+	}
+	return string(b)
+}
@@ -0,0 +1,73 @@
+package flog
+
+import (
+	"errors"
+	"net"
+	"os"
+	"syscall"
+)
+
+// journalWriter 把每条日志作为一个数据报发给journald，
+// 超过socket限制的日志写入临时文件后传递文件描述符
+type journalWriter struct {
+	conn *net.UnixConn
+}
+
+func dialJournal() (*net.UnixConn, error) {
+	return net.DialUnix("unixgram", nil, &net.UnixAddr{Name: journalSocket, Net: "unixgram"})
+}
+
+func (j *journalWriter) Write(p []byte) (int, error) {
+	_, err := j.conn.Write(p)
+	if errors.Is(err, syscall.EMSGSIZE) || errors.Is(err, syscall.ENOBUFS) {
+		err = j.writeFile(p)
+	} else if err != nil {
+		// journald重启后重新连接再试一次
+		if conn, e := dialJournal(); e == nil {
+			j.conn.Close()
+			j.conn = conn
+			_, err = conn.Write(p)
+		}
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (j *journalWriter) writeFile(p []byte) error {
+	f, err := os.CreateTemp("/dev/shm", "flog-journal-")
+	if err != nil {
+		if f, err = os.CreateTemp("", "flog-journal-"); err != nil {
+			return err
+		}
+	}
+	defer f.Close()
+	os.Remove(f.Name())
+
+	if _, err := f.Write(p); err != nil {
+		return err
+	}
+	_, _, err = j.conn.WriteMsgUnix(nil, syscall.UnixRights(int(f.Fd())), nil)
+	return err
+}
+
+func (j *journalWriter) Close() error {
+	return j.conn.Close()
+}
+
+// DialJournal 使用原生协议直接写入systemd journal，不经过/dev/log，
+// 默认使用JournalFormatter，字段作为journal的变量。
+// New的"<journald>"和journald://使用它
+func DialJournal(priority Priority, tag string, opts ...Option) (*Flog, error) {
+	conn, err := dialJournal()
+	if err != nil {
+		return nil, err
+	}
+
+	l := newFlog(&journalWriter{conn: conn}, priority, tag)
+	l.SetFormatter(JournalFormatter{})
+	l.apply(opts)
+	l.start()
+	return l, nil
+}
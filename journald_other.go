@@ -0,0 +1,8 @@
+//go:build !linux
+
+package flog
+
+// DialJournal 在没有systemd journal的平台上总是返回ErrNotSupported
+func DialJournal(priority Priority, tag string, opts ...Option) (*Flog, error) {
+	return nil, ErrNotSupported
+}
@@ -0,0 +1,94 @@
+package flog
+
+import (
+	"encoding/binary"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_journalFormatter(t *testing.T) {
+	r := &Record{
+		Priority: LOG_DAEMON | LOG_WARNING,
+		Time:     time.Date(2020, 3, 4, 5, 6, 7, 0, time.UTC),
+		Tag:      "app",
+		Pid:      42,
+		Msg:      "two\nlines\n",
+		Fields:   []Field{{"user-id", 7}, {"_hidden", "x"}, {"@", "dropped"}},
+	}
+
+	b := JournalFormatter{}.Format(nil, r)
+
+	msg := "two\nlines"
+	expect := "MESSAGE\n" + string(binary.LittleEndian.AppendUint64(nil, uint64(len(msg)))) + msg + "\n" +
+		"PRIORITY=4\nSYSLOG_FACILITY=3\nSYSLOG_IDENTIFIER=app\nSYSLOG_PID=42\nUSER_ID=7\nHIDDEN=x\n"
+	if string(b) != expect {
+		t.Errorf("Expect:%q, get:%q", expect, b)
+	}
+}
+
+func Test_journalKey(t *testing.T) {
+	tests := map[string]string{
+		"user":        "USER",
+		"http.status": "HTTP_STATUS",
+		"__x":         "X",
+		"9lives":      "LIVES",
+		"":            "",
+	}
+	for in, expect := range tests {
+		if k := journalKey(in); k != expect {
+			t.Errorf("%q Expect:%q, get:%q", in, expect, k)
+		}
+	}
+	if k := journalKey(strings.Repeat("a", 100)); k != strings.Repeat("A", 64) {
+		t.Errorf("Expect: 64 chars, get:%q", k)
+	}
+}
+
+func Test_dialJournal(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		if _, err := DialJournal(LOG_LOCAL0|LOG_INFO, "app"); err != ErrNotSupported {
+			t.Errorf("Expect:%v, get:%v", ErrNotSupported, err)
+		}
+		return
+	}
+
+	sock := filepath.Join(t.TempDir(), "journal.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: sock, Net: "unixgram"})
+	if err != nil {
+		t.Skip(err)
+	}
+	defer conn.Close()
+
+	old := journalSocket
+	journalSocket = sock
+	defer func() { journalSocket = old }()
+
+	w, err := New("journald://?tag=web", "local1:info", "app")
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	defer w.Close()
+	w.(*Flog).With("status", 200).Err("failed")
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	buf := make([]byte, 1024)
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+
+	expect := "MESSAGE=failed\nPRIORITY=3\nSYSLOG_FACILITY=17\nSYSLOG_IDENTIFIER=web\nSYSLOG_PID=" + strconv.Itoa(os.Getpid()) + "\nSTATUS=200\n"
+	if s := string(buf[:n]); s != expect {
+		t.Errorf("Expect:%q, get:%q", expect, s)
+	}
+
+	if _, err := NewFromConfig(Config{Target: "<journald>"}); err == nil {
+		t.Errorf("Expect: journald not supported in Config")
+	}
+}
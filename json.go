@@ -0,0 +1,132 @@
+package flog
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+// JSONFormatter 每条日志输出一行JSON对象，字段合并到对象中
+type JSONFormatter struct {
+	// 可以在其它goroutine格式化的同时修改
+	numeric atomic.Bool
+}
+
+func NewJSONFormatter() *JSONFormatter {
+	return new(JSONFormatter)
+}
+
+// SetNumericFacilitySeverity 开启后facility(0-23)和severity(0-7)
+// 以syslog的数值输出，而不是名字
+func (f *JSONFormatter) SetNumericFacilitySeverity(on bool) {
+	f.numeric.Store(on)
+}
+
+func (f *JSONFormatter) Format(b []byte, r *Record) []byte {
+	b = append(b, `{"time":`...)
+	b = appendJSONString(b, r.Time.Format(time.RFC3339Nano))
+
+	if f.numeric.Load() {
+		b = append(b, `,"severity":`...)
+		b = strconv.AppendInt(b, int64(r.Priority&severityMask), 10)
+		b = append(b, `,"facility":`...)
+		b = strconv.AppendInt(b, int64(r.Priority&facilityMask)>>3, 10)
+	} else {
+		b = append(b, `,"severity":`...)
+		b = appendJSONString(b, r.Priority.severityName())
+		b = append(b, `,"facility":`...)
+		b = appendJSONString(b, r.Priority.facilityName())
+	}
+
+	b = append(b, `,"tag":`...)
+	b = appendJSONString(b, r.Tag)
+	b = append(b, `,"pid":`...)
+	b = strconv.AppendInt(b, int64(r.Pid), 10)
+	b = append(b, `,"msg":`...)
+	b = appendJSONString(b, strings.TrimSuffix(r.Msg, "\n"))
+
+	for _, fd := range r.Fields {
+		b = append(b, ',')
+		b = appendJSONString(b, fd.Key)
+		b = append(b, ':')
+		b = appendJSONValue(b, fd.Value)
+	}
+
+	return append(b, "}\n"...)
+}
+
+func appendJSONValue(b []byte, v interface{}) []byte {
+	switch v := v.(type) {
+	case nil:
+		return append(b, "null"...)
+	case string:
+		return appendJSONString(b, v)
+	case bool:
+		return strconv.AppendBool(b, v)
+	case int:
+		return strconv.AppendInt(b, int64(v), 10)
+	case int64:
+		return strconv.AppendInt(b, v, 10)
+	case uint64:
+		return strconv.AppendUint(b, v, 10)
+	case float64:
+		// JSON没有NaN和Inf，输出为字符串"NaN"、"+Inf"、"-Inf"
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return appendJSONString(b, strconv.FormatFloat(v, 'g', -1, 64))
+		}
+		return strconv.AppendFloat(b, v, 'g', -1, 64)
+	case error:
+		return appendJSONString(b, v.Error())
+	case fmt.Stringer:
+		return appendJSONString(b, v.String())
+	}
+
+	if data, err := json.Marshal(v); err == nil {
+		return append(b, data...)
+	}
+	return appendJSONString(b, fieldString(v))
+}
+
+// appendJSONString 追加JSON字符串，UTF-8字符原样保留，非法字节替换为U+FFFD
+func appendJSONString(b []byte, s string) []byte {
+	b = append(b, '"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			switch {
+			case c == '"' || c == '\\':
+				b = append(b, '\\', c)
+			case c == '\n':
+				b = append(b, '\\', 'n')
+			case c == '\r':
+				b = append(b, '\\', 'r')
+			case c == '\t':
+				b = append(b, '\\', 't')
+			case c < 0x20 || c == 0x7f:
+				b = append(b, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0x0f])
+			default:
+				b = append(b, c)
+			}
+			i++
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			b = append(b, `\ufffd`...)
+		case r == '\u2028' || r == '\u2029':
+			b = append(b, `\u202`...)
+			b = append(b, hexDigits[r&0x0f])
+		default:
+			b = append(b, s[i:i+size]...)
+		}
+		i += size
+	}
+	return append(b, '"')
+}
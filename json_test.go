@@ -0,0 +1,98 @@
+package flog
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func Test_jsonNumericFacilitySeverity(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestFlog(&buf, LOG_FTP|LOG_DEBUG, "test")
+
+	f := NewJSONFormatter()
+	f.SetNumericFacilitySeverity(true)
+	l.SetFormatter(f)
+
+	l.Err("transfer failed")
+
+	var v struct {
+		Facility int
+		Severity int
+		Msg      string
+	}
+	if err := json.Unmarshal(buf.Bytes(), &v); err != nil {
+		t.Fatalf("Expect:nil, get:%v %q", err, buf.String())
+	}
+
+	if v.Facility != 11 {
+		t.Errorf("Expect:11, get:%d", v.Facility)
+	}
+	if v.Severity != 3 {
+		t.Errorf("Expect:3, get:%d", v.Severity)
+	}
+	if v.Msg != "transfer failed" {
+		t.Errorf("Expect:transfer failed, get:%s", v.Msg)
+	}
+
+	buf.Reset()
+	f.SetNumericFacilitySeverity(false)
+	l.Err("named")
+
+	var n struct {
+		Facility string
+		Severity string
+	}
+	if err := json.Unmarshal(buf.Bytes(), &n); err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	if n.Facility != "ftp" || n.Severity != "err" {
+		t.Errorf("Expect:ftp err, get:%s %s", n.Facility, n.Severity)
+	}
+}
+
+func Test_jsonNumericConcurrent(t *testing.T) {
+	var buf syncBuffer
+	l := newFlog(nopCloser{&buf}, LOG_LOCAL0|LOG_INFO, "test")
+	l.noclose = true
+	f := NewJSONFormatter()
+	l.SetFormatter(f)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			f.SetNumericFacilitySeverity(i%2 == 0)
+		}
+	}()
+
+	// 用-race运行时检查并发修改
+	for i := 0; i < 200; i++ {
+		f.Format(nil, &Record{Priority: LOG_LOCAL0 | LOG_INFO, Msg: "x"})
+		l.Info("x")
+	}
+	<-done
+}
+
+func Test_jsonNonFinite(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestFlog(&buf, LOG_LOCAL0|LOG_INFO, "test")
+	l.SetFormatter(NewJSONFormatter())
+
+	l.log(LOG_INFO, "m", []Field{
+		{"nan", math.NaN()},
+		{"inf", math.Inf(1)},
+		{"ninf", math.Inf(-1)},
+		{"f32", float32(math.Inf(1))},
+		{"x", 1.5},
+	})
+
+	var v map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &v); err != nil {
+		t.Fatalf("Expect: valid JSON, get:%v %q", err, buf.String())
+	}
+	if v["nan"] != "NaN" || v["inf"] != "+Inf" || v["ninf"] != "-Inf" || v["f32"] != "+Inf" || v["x"] != 1.5 {
+		t.Errorf("Expect: quoted non-finite floats, get:%v", v)
+	}
+}
@@ -0,0 +1,145 @@
+package flog
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+)
+
+// parseSeverity 解析级别名，如"debug"，带facility时只取severity
+func parseSeverity(level string) (Priority, error) {
+	p, err := log_level(level)
+	if err != nil {
+		return 0, err
+	}
+	return p & severityMask, nil
+}
+
+// setFilter 只修改过滤级别，不改变facility
+func (w *Flog) setFilter(filter Priority) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.filter = filter & severityMask
+}
+
+// toggleLevel 收到toggle时在a、b两个过滤级别之间切换，收到reset时恢复为a
+func (w *Flog) toggleLevel(a, b string, toggle, reset os.Signal) (stop func(), err error) {
+	pa, err := parseSeverity(a)
+	if err != nil {
+		return nil, err
+	}
+	pb, err := parseSeverity(b)
+	if err != nil {
+		return nil, err
+	}
+
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, toggle, reset)
+
+	quit := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		defer signal.Stop(c)
+
+		for {
+			select {
+			case sig := <-c:
+				_, filter, _ := w.level()
+				next := pa
+				if sig == toggle && filter&severityMask == pa {
+					next = pb
+				}
+				w.setFilter(next)
+				w.Notice("log level changed to " + next.severityName())
+			case <-quit:
+				return
+			case <-w.done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(quit) })
+		<-exited
+	}, nil
+}
+
+type levelBody struct {
+	Level string `json:"level"`
+}
+
+type levelHandler struct {
+	w *Flog
+}
+
+// LevelHandler 返回查看和修改w过滤级别的http.Handler：
+//
+//	GET  返回 {"level":"info"}
+//	PUT  设置级别，请求体为 {"level":"debug"}，也可以用表单或URL参数 level=debug
+//
+// 级别名同New的priority，只取severity部分。挂到内部管理端口上，
+// 排查线上问题时不需要重新部署
+func LevelHandler(w *Flog) http.Handler {
+	return levelHandler{w}
+}
+
+func (h levelHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		level, err := requestLevel(r)
+		if err == nil && level == "" {
+			err = errors.New("flog: missing level")
+		}
+		if err != nil {
+			writeLevel(rw, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		p, err := parseSeverity(level)
+		if err != nil {
+			writeLevel(rw, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		h.w.setFilter(p)
+	default:
+		rw.Header().Set("Allow", "GET, PUT")
+		writeLevel(rw, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+
+	_, filter, _ := h.w.level()
+	writeLevel(rw, http.StatusOK, levelBody{filter.severityName()})
+}
+
+// requestLevel 从JSON请求体、表单或URL参数中取出level
+func requestLevel(r *http.Request) (string, error) {
+	ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if ct == "application/json" {
+		var b levelBody
+		if err := json.NewDecoder(io.LimitReader(r.Body, 4096)).Decode(&b); err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(b.Level), nil
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(r.Form.Get("level")), nil
+}
+
+func writeLevel(rw http.ResponseWriter, code int, v interface{}) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(code)
+	json.NewEncoder(rw).Encode(v)
+}
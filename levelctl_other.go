@@ -0,0 +1,8 @@
+//go:build !unix
+
+package flog
+
+// ToggleLevelOnSignal 在没有SIGUSR1、SIGUSR2的平台上总是返回ErrNotSupported
+func (w *Flog) ToggleLevelOnSignal(a, b string) (stop func(), err error) {
+	return nil, ErrNotSupported
+}
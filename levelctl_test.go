@@ -0,0 +1,68 @@
+package flog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_levelHandler(t *testing.T) {
+	r := NewRecorder()
+	r.SetLevel("daemon:info")
+	h := LevelHandler(r.Flog)
+
+	do := func(method, body, ct string) (int, string) {
+		req := httptest.NewRequest(method, "/log/level", strings.NewReader(body))
+		if ct != "" {
+			req.Header.Set("Content-Type", ct)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code, strings.TrimSpace(rec.Body.String())
+	}
+
+	tests := []struct {
+		method, body, ct string
+		code             int
+		expect           string
+	}{
+		{"GET", "", "", http.StatusOK, `{"level":"info"}`},
+		{"PUT", `{"level":"debug"}`, "application/json", http.StatusOK, `{"level":"debug"}`},
+		{"PUT", "level=warning", "application/x-www-form-urlencoded", http.StatusOK, `{"level":"warning"}`},
+		{"PUT", `{"level":"nope"}`, "application/json", http.StatusBadRequest, `{"error":"flog: unknown severity \"NOPE\""}`},
+		{"PUT", "", "", http.StatusBadRequest, `{"error":"flog: missing level"}`},
+		{"DELETE", "", "", http.StatusMethodNotAllowed, `{"error":"method not allowed"}`},
+		{"GET", "", "", http.StatusOK, `{"level":"warning"}`},
+	}
+	for _, tt := range tests {
+		code, body := do(tt.method, tt.body, tt.ct)
+		if code != tt.code || body != tt.expect {
+			t.Errorf("%s %q Expect:%d %s, get:%d %s", tt.method, tt.body, tt.code, tt.expect, code, body)
+		}
+	}
+
+	if p := r.getPriority(); p != LOG_DAEMON|LOG_INFO {
+		t.Errorf("Expect: priority unchanged, get:%v", p)
+	}
+	r.Info("filtered")
+	r.Warning("kept")
+	if e, _ := r.LastEntry(); e.Msg != "kept" || len(r.Entries()) != 1 {
+		t.Errorf("Expect: only kept, get:%+v", r.Entries())
+	}
+}
+
+func Test_levelHandlerUnmaskedFilter(t *testing.T) {
+	r := NewRecorder()
+	r.SetPriority(LOG_LOCAL0|LOG_INFO, LOG_LOCAL0|LOG_DEBUG)
+
+	if _, f, _ := r.level(); f != LOG_DEBUG {
+		t.Errorf("Expect: filter masked to %v, get:%v", LOG_DEBUG, f)
+	}
+
+	rec := httptest.NewRecorder()
+	LevelHandler(r.Flog).ServeHTTP(rec, httptest.NewRequest("GET", "/log/level", nil))
+	if s := strings.TrimSpace(rec.Body.String()); rec.Code != http.StatusOK || s != `{"level":"debug"}` {
+		t.Errorf("Expect:%s, get:%d %s", `{"level":"debug"}`, rec.Code, s)
+	}
+}
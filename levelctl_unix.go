@@ -0,0 +1,14 @@
+//go:build unix
+
+package flog
+
+import (
+	"syscall"
+)
+
+// ToggleLevelOnSignal 收到SIGUSR1时过滤级别在a和b之间切换(如"info"和"debug")，
+// 收到SIGUSR2时恢复为a，切换后以Notice记录新的级别。
+// 返回的函数停止监听，日志Close后也会停止
+func (w *Flog) ToggleLevelOnSignal(a, b string) (stop func(), err error) {
+	return w.toggleLevel(a, b, syscall.SIGUSR1, syscall.SIGUSR2)
+}
@@ -0,0 +1,105 @@
+package flog
+
+import (
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// PublishExpvar 把Stats以name发布到expvar(/debug/vars)，
+// 按severity的统计以名字为key，如 {"written":{"info":10}}。
+// name已经发布过时expvar会panic
+func (w *Flog) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return w.Stats().vars()
+	}))
+}
+
+func (s Stats) vars() map[string]interface{} {
+	bySeverity := func(c [8]uint64) map[string]uint64 {
+		m := make(map[string]uint64, len(c))
+		for i, n := range c {
+			m[severityNames[i]] = n
+		}
+		return m
+	}
+
+	m := map[string]interface{}{
+		"written":          bySeverity(s.Written),
+		"dropped":          bySeverity(s.Dropped),
+		"bytes_written":    s.BytesWritten,
+		"write_errors":     s.WriteErrors,
+		"async_dropped":    s.AsyncDropped,
+		"queue_depth":      s.QueueDepth,
+		"buffered":         s.Buffered,
+		"truncated_bytes":  s.TruncatedBytes,
+		"formatter_panics": s.FormatterPanics,
+		"hook_errors":      s.HookErrors,
+		"spool_dropped":    s.SpoolDropped,
+	}
+	if !s.LastRotation.IsZero() {
+		m["last_rotation"] = s.LastRotation
+	}
+	return m
+}
+
+// MetricsHandler 以Prometheus的文本格式输出Stats，指标以flog_开头，
+// 带有tag标签，可以直接挂到/metrics或合并到已有的采集中
+func (w *Flog) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.WriteMetrics(rw)
+	})
+}
+
+// WriteMetrics 以Prometheus的文本格式把Stats写入out，见MetricsHandler
+func (w *Flog) WriteMetrics(out io.Writer) error {
+	s := w.Stats()
+	_, _, tag := w.level()
+	label := "tag=" + strconv.Quote(tag)
+
+	var b []byte
+	metric := func(name, typ, help string) {
+		b = fmt.Appendf(b, "# HELP flog_%s %s\n# TYPE flog_%s %s\n", name, help, name, typ)
+	}
+	value := func(name, labels string, v interface{}) {
+		b = fmt.Appendf(b, "flog_%s{%s} %v\n", name, labels, v)
+	}
+	bySeverity := func(name, help string, c [8]uint64) {
+		metric(name, "counter", help)
+		for i, n := range c {
+			value(name, label+",severity="+strconv.Quote(severityNames[i]), n)
+		}
+	}
+
+	bySeverity("messages_written_total", "Messages written by severity.", s.Written)
+	bySeverity("messages_dropped_total", "Messages dropped by rate limiting or write errors, by severity.", s.Dropped)
+
+	for _, m := range []struct {
+		name, typ, help string
+		v               interface{}
+	}{
+		{"bytes_written_total", "counter", "Bytes written to the output.", s.BytesWritten},
+		{"write_errors_total", "counter", "Failed writes to the output.", s.WriteErrors},
+		{"async_dropped_total", "counter", "Messages dropped because the async queue was full.", s.AsyncDropped},
+		{"spool_dropped_total", "counter", "Messages dropped because the spool was full.", s.SpoolDropped},
+		{"truncated_bytes_total", "counter", "Bytes cut by the maximum line length.", s.TruncatedBytes},
+		{"formatter_panics_total", "counter", "Formatter panics.", s.FormatterPanics},
+		{"hook_errors_total", "counter", "Errors returned by hooks.", s.HookErrors},
+		{"queue_depth", "gauge", "Messages waiting in the async queue.", s.QueueDepth},
+		{"buffered_bytes", "gauge", "Bytes waiting in the write buffer.", s.Buffered},
+	} {
+		metric(m.name, m.typ, m.help)
+		value(m.name, label, m.v)
+	}
+
+	if !s.LastRotation.IsZero() {
+		metric("last_rotation_timestamp_seconds", "gauge", "Time of the last file rotation.")
+		value("last_rotation_timestamp_seconds", label, float64(s.LastRotation.UnixNano())/1e9)
+	}
+
+	_, err := out.Write(b)
+	return err
+}
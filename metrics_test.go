@@ -0,0 +1,120 @@
+package flog
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_stats(t *testing.T) {
+	out := &failWriter{}
+	l := newFlog(out, LOG_LOCAL0|LOG_DEBUG, "test")
+	l.SetFormatter(CompactFormatter{})
+	l.now = (&fakeClock{t: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}).now
+
+	l.SetRateLimit(LOG_ERR, 1)
+	l.Info("info")
+	l.Err("first")
+	l.Err("limited")
+	out.fail = true
+	l.Info("lost")
+
+	s := l.Stats()
+	if s.Written[LOG_INFO] != 1 || s.Written[LOG_ERR] != 1 {
+		t.Errorf("Expect: 1 info 1 err written, get:%v", s.Written)
+	}
+	if s.Dropped[LOG_ERR] != 1 || s.Dropped[LOG_INFO] != 1 {
+		t.Errorf("Expect: 1 err 1 info dropped, get:%v", s.Dropped)
+	}
+	if s.WriteErrors != 1 {
+		t.Errorf("Expect: 1 write error, get:%d", s.WriteErrors)
+	}
+	if expect := uint64(len("6|info\n3|first\n")); s.BytesWritten != expect {
+		t.Errorf("Expect:%d, get:%d", expect, s.BytesWritten)
+	}
+	if !s.LastRotation.IsZero() {
+		t.Errorf("Expect: no rotation, get:%v", s.LastRotation)
+	}
+}
+
+func Test_statsFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.log")
+
+	l, err := File(file, LOG_LOCAL0|LOG_INFO, "test", WithBuffer(4096, 0), WithMaxSize(20))
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	defer l.Close()
+	l.SetFormatter(CompactFormatter{})
+
+	start := time.Now()
+	for _, m := range []string{"line-0", "line-1", "line-2"} {
+		l.Info(m)
+	}
+
+	s := l.Stats()
+	if s.LastRotation.Before(start) {
+		t.Errorf("Expect: rotated after %v, get:%v", start, s.LastRotation)
+	}
+	if s.Buffered != len("6|line-2\n") {
+		t.Errorf("Expect:%d, get:%d", len("6|line-2\n"), s.Buffered)
+	}
+}
+
+func Test_writeMetrics(t *testing.T) {
+	var buf syncBuffer
+	l := newFlog(nopCloser{&buf}, LOG_LOCAL0|LOG_INFO, "app")
+	l.noclose = true
+	l.Info("hello")
+
+	rec := httptest.NewRecorder()
+	l.MetricsHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	body := rec.Body.String()
+	for _, line := range []string{
+		"# TYPE flog_messages_written_total counter\n",
+		`flog_messages_written_total{tag="app",severity="info"} 1` + "\n",
+		`flog_messages_dropped_total{tag="app",severity="err"} 0` + "\n",
+		`flog_bytes_written_total{tag="app"} ` + strconv.Itoa(len(buf.String())) + "\n",
+		"# TYPE flog_queue_depth gauge\n",
+	} {
+		if !strings.Contains(body, line) {
+			t.Errorf("Expect: %q in %q", line, body)
+		}
+	}
+	if strings.Contains(body, "last_rotation") {
+		t.Errorf("Expect: no last_rotation without rotation")
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Expect: text/plain, get:%q", ct)
+	}
+}
+
+// expvar的名字不能重复发布，-count>1时每次使用新名字
+var expvarRuns atomic.Int32
+
+func Test_publishExpvar(t *testing.T) {
+	name := t.Name() + strconv.Itoa(int(expvarRuns.Add(1)))
+
+	var buf syncBuffer
+	l := newFlog(nopCloser{&buf}, LOG_LOCAL0|LOG_INFO, "app")
+	l.noclose = true
+	l.PublishExpvar(name)
+	l.Warning("careful")
+
+	var v struct {
+		Written map[string]uint64 `json:"written"`
+	}
+	if err := json.Unmarshal([]byte(expvar.Get(name).String()), &v); err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	if v.Written["warning"] != 1 || v.Written["info"] != 0 {
+		t.Errorf("Expect: 1 warning, get:%v", v.Written)
+	}
+}
@@ -0,0 +1,31 @@
+package flog
+
+// Middleware 在级别过滤之后、格式化之前处理日志，可以修改级别、消息和字段，
+// 返回drop为true时丢弃这条日志。只有返回的级别部分生效
+type Middleware func(p Priority, msg string, fields []Field) (Priority, string, []Field, bool)
+
+// Use 追加一个中间件，中间件按添加顺序执行
+func (w *Flog) Use(mw Middleware) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var mws []Middleware
+	if old := w.mws.Load(); old != nil {
+		mws = append(mws, *old...)
+	}
+	mws = append(mws, mw)
+	w.mws.Store(&mws)
+}
+
+func runMiddleware(mws []Middleware, r *Record) bool {
+	for _, mw := range mws {
+		p, msg, fields, drop := mw(r.Priority, r.Msg, r.Fields)
+		if drop {
+			return false
+		}
+		r.Priority = r.Priority&facilityMask | p&severityMask
+		r.Msg = msg
+		r.Fields = fields
+	}
+	return true
+}
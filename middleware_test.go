@@ -0,0 +1,39 @@
+package flog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func Test_middleware(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestFlog(&buf, LOG_LOCAL0|LOG_INFO, "test")
+	l.SetFormatter(CompactFormatter{})
+
+	// 增加字段
+	l.Use(func(p Priority, msg string, fields []Field) (Priority, string, []Field, bool) {
+		return p, msg, append(fields, Field{"host", "web1"}), false
+	})
+	// 脱敏并把含密码的日志升为Warning
+	l.Use(func(p Priority, msg string, fields []Field) (Priority, string, []Field, bool) {
+		if strings.Contains(msg, "password=") {
+			return LOG_WARNING, strings.ReplaceAll(msg, "password=secret", "password=***"), fields, false
+		}
+		return p, msg, fields, false
+	})
+	// 丢弃健康检查
+	l.Use(func(p Priority, msg string, fields []Field) (Priority, string, []Field, bool) {
+		return p, msg, fields, msg == "healthcheck"
+	})
+
+	l.Info("login password=secret")
+	l.Info("healthcheck")
+	l.Debug("filtered before middleware")
+	l.WithTTL(0).Notice("done")
+
+	expect := "4|login password=*** host=web1\n5|done ttl=0 host=web1\n"
+	if buf.String() != expect {
+		t.Errorf("Expect:%q, get:%q", expect, buf.String())
+	}
+}
@@ -0,0 +1,90 @@
+package flog
+
+import (
+	"errors"
+)
+
+type multiWriter struct {
+	ws []Writer
+}
+
+// MultiWriter 把每条日志写给所有writers，如同时写文件和远程syslog。
+// 级别过滤由各个writer自己决定；某个writer失败不影响其它writer，
+// 所有错误合并后返回
+func MultiWriter(writers ...Writer) Writer {
+	return &multiWriter{ws: writers}
+}
+
+// Multi 同MultiWriter，配合Filter给每个writer设置级别
+func Multi(writers ...Writer) Writer {
+	return MultiWriter(writers...)
+}
+
+func (m *multiWriter) do(f func(Writer) error) error {
+	var errs []error
+	for _, w := range m.ws {
+		if err := f(w); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m *multiWriter) Write(b []byte) (int, error) {
+	err := m.do(func(w Writer) error {
+		_, err := w.Write(b)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// Close 关闭所有writer
+func (m *multiWriter) Close() error {
+	return m.do(Writer.Close)
+}
+
+func (m *multiWriter) Emerg(s string) error {
+	return m.do(func(w Writer) error { return w.Emerg(s) })
+}
+
+func (m *multiWriter) Alert(s string) error {
+	return m.do(func(w Writer) error { return w.Alert(s) })
+}
+
+func (m *multiWriter) Crit(s string) error {
+	return m.do(func(w Writer) error { return w.Crit(s) })
+}
+
+func (m *multiWriter) Err(s string) error {
+	return m.do(func(w Writer) error { return w.Err(s) })
+}
+
+func (m *multiWriter) Warning(s string) error {
+	return m.do(func(w Writer) error { return w.Warning(s) })
+}
+
+func (m *multiWriter) Notice(s string) error {
+	return m.do(func(w Writer) error { return w.Notice(s) })
+}
+
+func (m *multiWriter) Info(s string) error {
+	return m.do(func(w Writer) error { return w.Info(s) })
+}
+
+func (m *multiWriter) Debug(s string) error {
+	return m.do(func(w Writer) error { return w.Debug(s) })
+}
+
+// enabled 有一个writer需要这条日志就返回true
+func (m *multiWriter) enabled(p Priority) bool {
+	for _, w := range m.ws {
+		e, ok := w.(interface{ enabled(Priority) bool })
+		if !ok || e.enabled(p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package flog
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type closeErrWriter struct {
+	failWriter
+}
+
+func (c *closeErrWriter) Close() error {
+	return errors.New("close failed")
+}
+
+func Test_multiWriter(t *testing.T) {
+	var debug, notice bytes.Buffer
+	a := newTestFlog(&debug, LOG_LOCAL0|LOG_DEBUG, "test")
+	b := newTestFlog(&notice, LOG_LOCAL0|LOG_NOTICE, "test")
+	a.SetFormatter(CompactFormatter{})
+	b.SetFormatter(CompactFormatter{})
+
+	m := MultiWriter(a, b)
+	m.Debug("debug")
+	m.Notice("notice")
+	m.Write([]byte("write"))
+
+	if s := debug.String(); s != "7|debug\n5|notice\n7|write\n" {
+		t.Errorf("Expect:%q, get:%q", "7|debug\n5|notice\n7|write\n", s)
+	}
+	if s := notice.String(); s != "5|notice\n5|write\n" {
+		t.Errorf("Expect:%q, get:%q", "5|notice\n5|write\n", s)
+	}
+}
+
+func Test_multiWriterError(t *testing.T) {
+	var ok bytes.Buffer
+	good := newTestFlog(&ok, LOG_LOCAL0|LOG_INFO, "test")
+	good.SetFormatter(CompactFormatter{})
+
+	bad := &closeErrWriter{failWriter{fail: true}}
+	m := MultiWriter(newFlog(bad, LOG_LOCAL0|LOG_INFO, "test"), good)
+
+	err := m.Info("hello")
+	if err == nil || !strings.Contains(err.Error(), "endpoint down") {
+		t.Errorf("Expect: endpoint down, get:%v", err)
+	}
+	if s := ok.String(); s != "6|hello\n" {
+		t.Errorf("Expect: other writer still written, get:%q", s)
+	}
+
+	if err := m.Close(); err == nil || !strings.Contains(err.Error(), "close failed") {
+		t.Errorf("Expect: close failed, get:%v", err)
+	}
+}
+
+func Test_multiFilter(t *testing.T) {
+	var all bytes.Buffer
+	file := newTestFlog(&all, LOG_LOCAL0|LOG_DEBUG, "test")
+	file.SetFormatter(CompactFormatter{})
+
+	sys := &writerOnly{new(bytes.Buffer)}
+	m := NewFormatWriter(Multi(file, Filter(sys, LOG_NOTICE)))
+
+	m.Notice("notice")
+	if s := sys.buf.String(); s != "notice notice" {
+		t.Errorf("Expect:%q, get:%q", "notice notice", s)
+	}
+
+	m.Info("info")
+	m.Debugf("debug %d", 1)
+	if s := sys.buf.String(); s != "notice notice" {
+		t.Errorf("Expect: info filtered, get:%q", s)
+	}
+	if s := all.String(); s != "5|notice\n6|info\n7|debug 1\n" {
+		t.Errorf("Expect:%q, get:%q", "5|notice\n6|info\n7|debug 1\n", s)
+	}
+
+	// 所有writer都不需要时不格式化
+	n := 0
+	NewFormatWriter(Filter(sys, LOG_ERR)).Warningf("%v", countingStringer{&n})
+	if n != 0 {
+		t.Errorf("Expect:0, get:%d", n)
+	}
+}
@@ -0,0 +1,129 @@
+package flog
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// Named 是按名字区分的子日志，与所属的Flog共用输出，
+// 日志带有logger字段，过滤级别可以单独设置，见SetLevels
+type Named struct {
+	*Entry
+	name string
+
+	// 过滤级别，-1表示使用Flog的过滤级别
+	level atomic.Int32
+}
+
+// Named 返回名为name的子日志，同一个名字总是返回同一个子日志。
+// 名字可以用.分级，如"http.server"，未单独设置级别时使用"http"的级别
+func (w *Flog) Named(name string) *Named {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if n, ok := w.named[name]; ok {
+		return n
+	}
+
+	n := &Named{name: name}
+	n.Entry = &Entry{l: w, fields: []Field{{"logger", name}}, named: n}
+	n.level.Store(int32(w.namedLevel(name)))
+
+	if w.named == nil {
+		w.named = make(map[string]*Named)
+	}
+	w.named[name] = n
+	return n
+}
+
+// Named 返回名为 n的名字.sub 的子日志
+func (n *Named) Named(sub string) *Named {
+	return n.l.Named(n.name + "." + sub)
+}
+
+func (n *Named) Name() string {
+	return n.name
+}
+
+// SetSeverity 单独设置这个名字的过滤级别，名字是它前缀的子日志也随之改变
+func (n *Named) SetSeverity(severity Priority) {
+	w := n.l
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.levels == nil {
+		w.levels = make(map[string]Priority)
+	}
+	w.levels[n.name] = severity & severityMask
+	w.refreshNamed()
+}
+
+// filter 返回子日志的过滤级别，def是Flog的过滤级别
+func (n *Named) filter(def Priority) Priority {
+	if v := n.level.Load(); v >= 0 {
+		return Priority(v)
+	}
+	return def
+}
+
+// SetLevels 按spec设置过滤级别，如"info,db=debug,http=warning"：
+// 不带名字的一项是Flog的过滤级别，其余为对应名字及其下级的过滤级别。
+// 之前按名字设置的级别全部被替换
+func (w *Flog) SetLevels(spec string) error {
+	base := Priority(-1)
+	levels := make(map[string]Priority)
+
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		name, sev, ok := strings.Cut(item, "=")
+		if !ok {
+			sev, name = name, ""
+		}
+		p, err := log_level(strings.TrimSpace(sev))
+		if err != nil || strings.Contains(sev, ":") {
+			return fmt.Errorf("flog: invalid level %q", item)
+		}
+
+		if name = strings.TrimSpace(name); name == "" {
+			base = p & severityMask
+		} else {
+			levels[name] = p & severityMask
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if base >= 0 {
+		w.filter = base
+	}
+	w.levels = levels
+	w.refreshNamed()
+	return nil
+}
+
+// refreshNamed 重新计算所有子日志的过滤级别，调用时必须持有mu
+func (w *Flog) refreshNamed() {
+	for name, n := range w.named {
+		n.level.Store(int32(w.namedLevel(name)))
+	}
+}
+
+// namedLevel 返回name最长的已设置前缀的级别，没有时返回-1。调用时必须持有mu
+func (w *Flog) namedLevel(name string) Priority {
+	for {
+		if p, ok := w.levels[name]; ok {
+			return p
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			return -1
+		}
+		name = name[:i]
+	}
+}
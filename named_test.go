@@ -0,0 +1,60 @@
+package flog
+
+import (
+	"bytes"
+	"testing"
+)
+
+func Test_named(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestFlog(&buf, LOG_LOCAL0|LOG_NOTICE, "app")
+	l.SetFormatter(CompactFormatter{})
+
+	db := l.Named("db")
+	srv := l.Named("http").Named("server")
+	if l.Named("db") != db || srv.Name() != "http.server" {
+		t.Errorf("Expect: same logger for same name")
+	}
+
+	if err := l.SetLevels("info,db=debug,http=warning"); err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	l.Debug("root filtered")
+	l.Info("root")
+	db.Debug("query")
+	db.With("rows", 3).Debugf("done in %dms", 5)
+	srv.Notice("filtered")
+	srv.Warning("slow")
+	l.Named("cache").Debug("filtered")
+	l.Named("cache").Info("miss")
+
+	// 单独设置子日志
+	srv.SetSeverity(LOG_DEBUG)
+	srv.Debug("request")
+	l.Named("http").Info("filtered")
+
+	expect := "6|root\n" +
+		"7|query logger=db\n" +
+		"7|done in 5ms logger=db rows=3\n" +
+		"4|slow logger=http.server\n" +
+		"6|miss logger=cache\n" +
+		"7|request logger=http.server\n"
+	if buf.String() != expect {
+		t.Errorf("Expect:%q, get:%q", expect, buf.String())
+	}
+
+	// spec替换之前的设置
+	l.SetLevels("db=err")
+	buf.Reset()
+	srv.Info("inherit")
+	db.Warning("filtered")
+	if s := buf.String(); s != "6|inherit logger=http.server\n" {
+		t.Errorf("Expect:%q, get:%q", "6|inherit logger=http.server\n", s)
+	}
+
+	for _, spec := range []string{"db=nope", "local1:info", "=info,x"} {
+		if err := l.SetLevels(spec); err == nil {
+			t.Errorf("%q Expect: error", spec)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package flog
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultDialTimeout = 5 * time.Second
+	defaultMinBackoff  = 100 * time.Millisecond
+	defaultMaxBackoff  = 30 * time.Second
+)
+
+type netOptions struct {
+	dialTimeout  time.Duration
+	writeTimeout time.Duration
+	tls          *tls.Config
+	minBackoff   time.Duration
+	maxBackoff   time.Duration
+	spoolDir     string
+	spoolMax     int64
+}
+
+// WithDialTimeout 设置网络日志连接的超时，默认5秒
+func WithDialTimeout(d time.Duration) Option {
+	return func(w *Flog) {
+		w.opts.net.dialTimeout = d
+	}
+}
+
+// WithWriteTimeout 设置网络日志每次写入的超时，默认不超时
+func WithWriteTimeout(d time.Duration) Option {
+	return func(w *Flog) {
+		w.opts.net.writeTimeout = d
+	}
+}
+
+// WithTLSConfig 设置tls://使用的配置，默认按地址中的主机名校验证书
+func WithTLSConfig(c *tls.Config) Option {
+	return func(w *Flog) {
+		w.opts.net.tls = c
+	}
+}
+
+// WithReconnectBackoff 设置断线重连的间隔：第一次失败后等待min，
+// 之后每次失败加倍，最长max。等待期间的日志直接返回错误。默认100ms到30s
+func WithReconnectBackoff(min, max time.Duration) Option {
+	return func(w *Flog) {
+		w.opts.net.minBackoff = min
+		w.opts.net.maxBackoff = max
+	}
+}
+
+// netWriter 把每条日志作为一条syslog消息发送：
+// udp每条一个数据报，tcp等流式连接使用RFC6587的octet-counting分帧("长度 消息")。
+// 连接断开后按退避间隔重连
+type netWriter struct {
+	dial   func() (net.Conn, error)
+	now    func() time.Time
+	conn   net.Conn
+	stream bool
+	opts   netOptions
+	buf    []byte
+	spool  *spool
+
+	backoff time.Duration
+	retryAt time.Time
+	lastErr error
+}
+
+func newNetWriter(network, raddr string, opts netOptions) *netWriter {
+	if opts.dialTimeout <= 0 {
+		opts.dialTimeout = defaultDialTimeout
+	}
+	if opts.minBackoff <= 0 {
+		opts.minBackoff = defaultMinBackoff
+	}
+	if opts.maxBackoff < opts.minBackoff {
+		opts.maxBackoff = defaultMaxBackoff
+	}
+
+	n := &netWriter{now: time.Now, stream: !isPacketNetwork(network), opts: opts}
+	n.dial = func() (net.Conn, error) {
+		d := &net.Dialer{Timeout: opts.dialTimeout}
+		if network == "tls" {
+			return tls.DialWithDialer(d, "tcp", raddr, opts.tls)
+		}
+		return d.Dial(network, raddr)
+	}
+	return n
+}
+
+func isPacketNetwork(network string) bool {
+	switch network {
+	case "udp", "udp4", "udp6", "unixgram":
+		return true
+	}
+	return false
+}
+
+// connect 建立连接，处于退避等待时返回上一次的错误
+func (n *netWriter) connect() error {
+	now := n.now()
+	if now.Before(n.retryAt) {
+		return fmt.Errorf("flog: reconnect in %v: %w", n.retryAt.Sub(now), n.lastErr)
+	}
+
+	conn, err := n.dial()
+	if err != nil {
+		if n.backoff == 0 {
+			n.backoff = n.opts.minBackoff
+		} else if n.backoff *= 2; n.backoff > n.opts.maxBackoff {
+			n.backoff = n.opts.maxBackoff
+		}
+		n.retryAt = now.Add(n.backoff)
+		n.lastErr = err
+		return err
+	}
+
+	n.conn = conn
+	n.backoff = 0
+	n.retryAt = time.Time{}
+	return nil
+}
+
+func (n *netWriter) Write(p []byte) (int, error) {
+	msg := bytes.TrimSuffix(p, []byte{'\n'})
+
+	if n.spool != nil {
+		if err := n.spoolWrite(msg); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+
+	n.buf = n.frame(n.buf[:0], msg)
+	if err := n.send(n.buf); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// frame 把msg按连接类型分帧后追加到b
+func (n *netWriter) frame(b, msg []byte) []byte {
+	if n.stream {
+		b = strconv.AppendInt(b, int64(len(msg)), 10)
+		b = append(b, ' ')
+	}
+	return append(b, msg...)
+}
+
+// send 写入分帧后的b，写入失败时重连后再试一次
+func (n *netWriter) send(b []byte) error {
+	var err error
+	for i := 0; i < 2; i++ {
+		if n.conn == nil {
+			if err = n.connect(); err != nil {
+				return err
+			}
+		}
+
+		if n.opts.writeTimeout > 0 {
+			n.conn.SetWriteDeadline(n.now().Add(n.opts.writeTimeout))
+		}
+		if _, err = n.conn.Write(b); err == nil {
+			return nil
+		}
+
+		n.conn.Close()
+		n.conn = nil
+	}
+	return err
+}
+
+func (n *netWriter) Close() error {
+	var err error
+	if n.conn != nil {
+		err = n.conn.Close()
+	}
+	if n.spool != nil {
+		if e := n.spool.Close(); err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// DialNet 不经过log/syslog直接连接远程syslog，network可以是tcp、udp、unix、
+// unixgram或tls，默认以RFC3164格式发送。连接断开后自动重连，
+// 见WithDialTimeout、WithWriteTimeout、WithTLSConfig、WithReconnectBackoff、WithSpool。
+// New的网络地址(tcp://、udp://、unix://、tls://)使用它
+func DialNet(network, raddr string, priority Priority, tag string, opts ...Option) (*Flog, error) {
+	l := newFlog(nil, priority, tag)
+	l.SetFormatter(SyslogFormatter{})
+	l.apply(opts)
+
+	nw := newNetWriter(network, raddr, l.opts.net)
+	if d := l.opts.net.spoolDir; d != "" {
+		s, err := openSpool(d, network, raddr, l.opts.net.spoolMax)
+		if err != nil {
+			return nil, err
+		}
+		nw.spool = s
+		l.spool = s
+
+		// 连接失败时先存入队列，补发上次没有发出的日志
+		if nw.connect() == nil {
+			nw.replay()
+		}
+	} else if err := nw.connect(); err != nil {
+		return nil, err
+	}
+	l.w = nw
+
+	l.start()
+	return l, nil
+}
+
+// DialRFC5424 同DialNet，以RFC5424格式发送(含版本、主机名、app-name、
+// procid、msgid和STRUCTURED-DATA)，tag作为app-name。New的网络地址带
+// ?format=rfc5424 时效果相同，如 tcp://host:514?format=rfc5424
+func DialRFC5424(network, raddr string, priority Priority, tag string, opts ...Option) (*Flog, error) {
+	opts = append([]Option{WithFormat(FormatRFC5424)}, opts...)
+	return DialNet(network, raddr, priority, tag, opts...)
+}
@@ -0,0 +1,250 @@
+package flog
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_dialRFC5424UDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer conn.Close()
+
+	w, err := New("udp://"+conn.LocalAddr().String()+"?format=rfc5424", "local0:info", "app")
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	defer w.Close()
+
+	l := w.(*Flog)
+	l.getFormatter().(*RFC5424Formatter).Hostname = "host"
+	l.now = (&fakeClock{t: time.Date(2020, 3, 4, 5, 6, 7, 0, time.UTC)}).now
+	l.WithSD("origin@32473", map[string]string{"ip": "10.0.0.1"}).Info("hello")
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	buf := make([]byte, 1024)
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+
+	expect := fmt.Sprintf(`<134>1 2020-03-04T05:06:07.000000Z host app %d - [origin@32473 ip="10.0.0.1"] hello`, os.Getpid())
+	if s := string(buf[:n]); s != expect {
+		t.Errorf("Expect:%q, get:%q", expect, s)
+	}
+}
+
+func Test_dialRFC5424TCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer ln.Close()
+
+	l, err := DialRFC5424("tcp", ln.Addr().String(), LOG_LOCAL0|LOG_INFO, "app")
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	defer conn.Close()
+
+	l.Info("one")
+	l.Info("two\nlines")
+	l.Close()
+
+	// octet-counting: "长度 消息"
+	r := bufio.NewReader(conn)
+	for _, msg := range []string{"one", "two\nlines"} {
+		size, err := r.ReadString(' ')
+		if err != nil {
+			t.Fatalf("Expect:nil, get:%v", err)
+		}
+		n, _ := strconv.Atoi(strings.TrimSpace(size))
+		frame := make([]byte, n)
+		if _, err := io.ReadFull(r, frame); err != nil {
+			t.Fatalf("Expect:nil, get:%v", err)
+		}
+		if !strings.HasPrefix(string(frame), "<134>1 ") || !strings.HasSuffix(string(frame), " - "+msg) {
+			t.Errorf("Expect: rfc5424 frame ending in %q, get:%q", msg, frame)
+		}
+	}
+}
+
+func Test_netReconnect(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer ln.Close()
+
+	conns := make(chan net.Conn, 2)
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conns <- c
+		}
+	}()
+
+	l, err := DialNet("tcp", ln.Addr().String(), LOG_LOCAL0|LOG_INFO, "app", WithFormat(FormatCompact), WithReconnectBackoff(time.Millisecond, 10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	defer l.Close()
+
+	c1 := <-conns
+	l.Info("first")
+	buf := make([]byte, 64)
+	c1.SetReadDeadline(time.Now().Add(time.Second))
+	if n, _ := c1.Read(buf); string(buf[:n]) != "7 6|first" {
+		t.Errorf("Expect:%q, get:%q", "7 6|first", buf[:n])
+	}
+	c1.Close()
+
+	// 对端关闭后写入失败，重连后继续发送
+	deadline := time.Now().Add(5 * time.Second)
+	var c2 net.Conn
+	for c2 == nil && time.Now().Before(deadline) {
+		l.Info("again")
+		select {
+		case c2 = <-conns:
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	if c2 == nil {
+		t.Fatalf("Expect: reconnected")
+	}
+	defer c2.Close()
+
+	c2.SetReadDeadline(time.Now().Add(time.Second))
+	n, _ := c2.Read(buf)
+	if !strings.Contains(string(buf[:n]), "6|again") {
+		t.Errorf("Expect: message on new connection, get:%q", buf[:n])
+	}
+}
+
+func Test_netBackoff(t *testing.T) {
+	c := &fakeClock{t: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	nw := newNetWriter("tcp", "127.0.0.1:1", netOptions{minBackoff: time.Second, maxBackoff: 4 * time.Second})
+	nw.now = c.now
+
+	dials := 0
+	nw.dial = func() (net.Conn, error) {
+		dials++
+		return nil, errors.New("refused")
+	}
+
+	for _, step := range []struct {
+		wait  time.Duration
+		dials int
+	}{
+		{0, 1},                      // 失败，等待1s
+		{500 * time.Millisecond, 1}, // 等待中不拨号
+		{time.Second, 2},            // 失败，等待2s
+		{2 * time.Second, 3},        // 失败，等待4s
+		{4 * time.Second, 4},        // 失败，最长4s
+		{3 * time.Second, 4},
+		{time.Second, 5},
+	} {
+		c.add(step.wait)
+		if _, err := nw.Write([]byte("x\n")); err == nil {
+			t.Errorf("Expect: error")
+		}
+		if dials != step.dials {
+			t.Errorf("Expect:%d dials, get:%d", step.dials, dials)
+		}
+	}
+
+	// 恢复后重置退避
+	a, b := net.Pipe()
+	defer b.Close()
+	go io.Copy(io.Discard, b)
+	nw.dial = func() (net.Conn, error) { return a, nil }
+	c.add(4 * time.Second)
+	if _, err := nw.Write([]byte("x\n")); err != nil {
+		t.Errorf("Expect:nil, get:%v", err)
+	}
+	if nw.backoff != 0 {
+		t.Errorf("Expect: backoff reset, get:%v", nw.backoff)
+	}
+}
+
+func Test_netTLS(t *testing.T) {
+	cert := testCert(t)
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		t.Skip(err)
+	}
+	defer ln.Close()
+
+	got := make(chan string, 1)
+	go func() {
+		c, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		buf := make([]byte, 256)
+		n, _ := c.Read(buf)
+		got <- string(buf[:n])
+	}()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert.Leaf)
+	w, err := New("tls://"+ln.Addr().String(), "local0:info", "app", WithTLSConfig(&tls.Config{RootCAs: pool, ServerName: "localhost"}), WithFormat(FormatCompact))
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	defer w.Close()
+	w.Info("secure")
+
+	select {
+	case s := <-got:
+		if s != "8 6|secure" {
+			t.Errorf("Expect:%q, get:%q", "8 6|secure", s)
+		}
+	case <-time.After(2 * time.Second):
+		t.Errorf("Expect: message over tls")
+	}
+}
+
+func testCert(t *testing.T) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, _ := x509.ParseCertificate(der)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
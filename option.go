@@ -0,0 +1,117 @@
+package flog
+
+import (
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Option 是New和File的可选配置
+type Option func(*Flog)
+
+type options struct {
+	startupRecord   bool
+	datasync        time.Duration
+	maxSize         int64
+	maxBackups      int
+	location        *time.Location
+	buffer          int
+	flushEvery      time.Duration
+	flushOnErr      bool
+	async           int
+	asyncSPSC       bool
+	overflow        Overflow
+	compress        bool
+	compressIndexed bool
+	maxTotal        int64
+	net             netOptions
+	stderr          bool
+}
+
+func (w *Flog) apply(opts []Option) {
+	for _, opt := range opts {
+		opt(w)
+	}
+}
+
+// start 在日志创建完成后调用
+func (w *Flog) start() {
+	if w.opts.async > 0 {
+		w.startAsync()
+	}
+	if w.opts.startupRecord {
+		w.writeStartupRecord()
+	}
+}
+
+// EmitStartupRecord 创建日志后立即以Info级别写一条记录，
+// 包含Go版本、系统、CPU数、pid、主机名以及日志的级别和格式
+func EmitStartupRecord(on bool) Option {
+	return func(w *Flog) {
+		w.opts.startupRecord = on
+	}
+}
+
+func (w *Flog) writeStartupRecord() {
+	host, _ := os.Hostname()
+
+	var b strings.Builder
+	b.WriteString("logger initialized")
+	kv := func(k, v string) {
+		b.WriteByte(' ')
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(v)
+	}
+
+	kv("go", runtime.Version())
+	kv("os", runtime.GOOS)
+	kv("arch", runtime.GOARCH)
+	kv("cpus", strconv.Itoa(runtime.NumCPU()))
+	kv("pid", strconv.Itoa(os.Getpid()))
+	kv("host", host)
+	kv("level", (w.priority&facilityMask | w.filter).String())
+	kv("format", formatterName(w.getFormatter()))
+
+	w.Info(b.String())
+}
+
+// WithDatasync 文件不再以O_SYNC打开，改为每隔interval调用一次fdatasync
+// (不支持的平台上为fsync)，以少量数据风险换取吞吐，只对文件有效
+func WithDatasync(interval time.Duration) Option {
+	return func(w *Flog) {
+		w.opts.datasync = interval
+	}
+}
+
+// WithMaxSize 文件超过size字节时轮转：app.log改名为app.log.1，
+// 已有的备份依次后移，然后重新创建app.log
+func WithMaxSize(size int64) Option {
+	return func(w *Flog) {
+		w.opts.maxSize = size
+	}
+}
+
+// WithMaxBackups 轮转时最多保留n个备份，0表示全部保留。
+// 按日期切换文件时同样只保留最近n个旧文件
+func WithMaxBackups(n int) Option {
+	return func(w *Flog) {
+		w.opts.maxBackups = n
+	}
+}
+
+// WithLocation 设置按日期切换文件时使用的时区，默认为本地时区
+func WithLocation(loc *time.Location) Option {
+	return func(w *Flog) {
+		w.opts.location = loc
+	}
+}
+
+// WithFormat 选择内置的输出格式，如FormatJSON每条日志输出一行JSON
+func WithFormat(f Format) Option {
+	return func(w *Flog) {
+		w.SetFormat(f)
+	}
+}
@@ -0,0 +1,89 @@
+package flog
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func Test_emitStartupRecord(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "startup.log")
+
+	_, err := File(file, LOG_DAEMON|LOG_DEBUG, "test", EmitStartupRecord(true))
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+
+	s := string(data)
+	if !strings.Contains(s, " INFO test[") {
+		t.Errorf("Expect: INFO severity, get:%q", s)
+	}
+	for _, v := range []string{"go=" + runtime.Version(), "level=daemon:debug", "format=human"} {
+		if !strings.Contains(s, v) {
+			t.Errorf("Expect:%q in %q", v, s)
+		}
+	}
+}
+
+func Test_withFormat(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "json.log")
+
+	l, err := New(file, "local0:info", "test", WithFormat(FormatJSON))
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	l.Info("Info 这个应该显示 \"quoted\"")
+	l.Warning("second")
+	l.Close()
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expect:2 lines, get:%q", data)
+	}
+
+	var v map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &v); err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	for k, expect := range map[string]interface{}{
+		"severity": "info",
+		"facility": "local0",
+		"tag":      "test",
+		"pid":      float64(os.Getpid()),
+		"msg":      "Info 这个应该显示 \"quoted\"",
+	} {
+		if v[k] != expect {
+			t.Errorf("%s Expect:%v, get:%v", k, expect, v[k])
+		}
+	}
+	if !strings.Contains(lines[0], "这个应该显示") {
+		t.Errorf("Expect: UTF-8 kept unescaped, get:%s", lines[0])
+	}
+}
+
+func Test_formatDefault(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "text.log")
+
+	l, err := File(file, LOG_LOCAL0|LOG_INFO, "test")
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	defer l.Close()
+
+	if name := formatterName(l.getFormatter()); name != "human" {
+		t.Errorf("Expect:human, get:%s", name)
+	}
+}
@@ -0,0 +1,45 @@
+package flog
+
+import (
+	"os"
+	"sync"
+)
+
+// 进程的pid不会变化，不必每条日志都调用一次os.Getpid
+var pid = os.Getpid()
+
+// 超过这个大小的缓冲区不放回池中，避免偶尔的大日志长期占用内存
+const maxPooledBuffer = 64 * 1024
+
+var recordPool = sync.Pool{
+	New: func() interface{} { return new(Record) },
+}
+
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, 0, 256)
+		return &b
+	},
+}
+
+func getRecord() *Record {
+	return recordPool.Get().(*Record)
+}
+
+// putRecord 清空r并放回池中，r不能再被使用
+func putRecord(r *Record) {
+	*r = Record{}
+	recordPool.Put(r)
+}
+
+func getBuffer() *[]byte {
+	return bufferPool.Get().(*[]byte)
+}
+
+func putBuffer(b *[]byte) {
+	if cap(*b) > maxPooledBuffer {
+		return
+	}
+	*b = (*b)[:0]
+	bufferPool.Put(b)
+}
@@ -0,0 +1,74 @@
+package flog
+
+import (
+	"strings"
+)
+
+// 前缀中可以使用的级别名，不区分大小写
+var prefixSeverities = map[string]Priority{
+	"emerg":     LOG_EMERG,
+	"emergency": LOG_EMERG,
+	"panic":     LOG_EMERG,
+	"alert":     LOG_ALERT,
+	"crit":      LOG_CRIT,
+	"critical":  LOG_CRIT,
+	"fatal":     LOG_CRIT,
+	"err":       LOG_ERR,
+	"error":     LOG_ERR,
+	"warn":      LOG_WARNING,
+	"warning":   LOG_WARNING,
+	"notice":    LOG_NOTICE,
+	"info":      LOG_INFO,
+	"debug":     LOG_DEBUG,
+	"trace":     LOG_DEBUG,
+}
+
+// SetPrefixDetection 开启后Write识别消息开头的级别前缀，
+// 去掉前缀后以对应的级别写入，没有前缀时仍使用默认级别。支持的形式：
+//
+//	<3>       sd-daemon的数字级别，0-7
+//	<err>     尖括号中的级别名
+//	[WARN]    方括号中的级别名
+//	ERROR:    级别名后跟冒号
+//
+// 级别名不区分大小写，如err、error、warn、warning、crit、fatal、debug、trace
+func (w *Flog) SetPrefixDetection(on bool) {
+	w.prefixes.Store(on)
+}
+
+// detectSeverity 识别s开头的级别前缀，返回级别和去掉前缀及其后空白的消息
+func detectSeverity(s string) (Priority, string, bool) {
+	if s == "" {
+		return 0, s, false
+	}
+
+	var name, rest string
+	switch s[0] {
+	case '<', '[':
+		end := byte('>')
+		if s[0] == '[' {
+			end = ']'
+		}
+		i := strings.IndexByte(s, end)
+		if i < 0 {
+			return 0, s, false
+		}
+		name, rest = s[1:i], s[i+1:]
+
+		if s[0] == '<' && len(name) == 1 && name[0] >= '0' && name[0] <= '7' {
+			return Priority(name[0] - '0'), strings.TrimLeft(rest, " \t"), true
+		}
+	default:
+		i := strings.IndexByte(s, ':')
+		if i < 0 {
+			return 0, s, false
+		}
+		name, rest = s[:i], s[i+1:]
+	}
+
+	p, ok := prefixSeverities[strings.ToLower(name)]
+	if !ok {
+		return 0, s, false
+	}
+	return p, strings.TrimLeft(rest, " \t"), true
+}
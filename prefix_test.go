@@ -0,0 +1,54 @@
+package flog
+
+import (
+	"bytes"
+	"testing"
+)
+
+func Test_detectSeverity(t *testing.T) {
+	tests := []struct {
+		in   string
+		p    Priority
+		rest string
+		ok   bool
+	}{
+		{"<3>disk failed", LOG_ERR, "disk failed", true},
+		{"<7> debug", LOG_DEBUG, "debug", true},
+		{"<err>disk failed", LOG_ERR, "disk failed", true},
+		{"[WARN] slow query", LOG_WARNING, "slow query", true},
+		{"[Info]", LOG_INFO, "", true},
+		{"ERROR: boom", LOG_ERR, "boom", true},
+		{"fatal:exit", LOG_CRIT, "exit", true},
+		{"<8>bad", 0, "<8>bad", false},
+		{"<html>", 0, "<html>", false},
+		{"[id=1] no level", 0, "[id=1] no level", false},
+		{"note: nothing", 0, "note: nothing", false},
+		{"plain", 0, "plain", false},
+		{"", 0, "", false},
+	}
+
+	for _, tt := range tests {
+		p, rest, ok := detectSeverity(tt.in)
+		if p != tt.p || rest != tt.rest || ok != tt.ok {
+			t.Errorf("%q Expect:%v %q %v, get:%v %q %v", tt.in, tt.p, tt.rest, tt.ok, p, rest, ok)
+		}
+	}
+}
+
+func Test_prefixDetection(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestFlog(&buf, LOG_LOCAL0|LOG_INFO, "test")
+	l.SetFormatter(CompactFormatter{})
+
+	l.Write([]byte("[ERROR] off"))
+	l.SetPrefixDetection(true)
+	l.Write([]byte("[ERROR] on"))
+	l.Write([]byte("<4>warn"))
+	l.Write([]byte("DEBUG: filtered"))
+	l.Write([]byte("default"))
+
+	expect := "6|[ERROR] off\n3|on\n4|warn\n6|default\n"
+	if buf.String() != expect {
+		t.Errorf("Expect:%q, get:%q", expect, buf.String())
+	}
+}
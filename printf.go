@@ -0,0 +1,168 @@
+package flog
+
+import (
+	"fmt"
+)
+
+// FormatWriter 在Writer的基础上增加了printf风格的方法，
+// *syslog.Writer只实现了Writer
+type FormatWriter interface {
+	Writer
+	Emergf(format string, args ...interface{}) error
+	Alertf(format string, args ...interface{}) error
+	Critf(format string, args ...interface{}) error
+	Errf(format string, args ...interface{}) error
+	Warningf(format string, args ...interface{}) error
+	Noticef(format string, args ...interface{}) error
+	Infof(format string, args ...interface{}) error
+	Debugf(format string, args ...interface{}) error
+}
+
+// enabled 报告p级别的日志是否需要格式化。
+// 开启崩溃环时被过滤的日志也要记录，所以总是需要
+func (w *Flog) enabled(p Priority) bool {
+	_, filter, _ := w.level()
+	return w.ring != nil || filter >= p&severityMask
+}
+
+// logf 在级别过滤之后才格式化，被过滤的日志不调用Sprintf
+func (w *Flog) logf(p Priority, format string, args []interface{}) error {
+	if !w.enabled(p) {
+		return nil
+	}
+	_, err := w.writeAndRetry(p, fmt.Sprintf(format, args...))
+	return err
+}
+
+func (e *Entry) enabled(p Priority) bool {
+	if e.named == nil {
+		return e.l.enabled(p)
+	}
+	_, filter, _ := e.l.level()
+	return e.l.ring != nil || e.named.filter(filter) >= p&severityMask
+}
+
+func (e *Entry) logf(p Priority, format string, args []interface{}) error {
+	if !e.enabled(p) {
+		return nil
+	}
+	_, err := e.log(p, fmt.Sprintf(format, args...))
+	return err
+}
+
+func (w *Flog) Emergf(format string, args ...interface{}) error {
+	return w.logf(LOG_EMERG, format, args)
+}
+
+func (w *Flog) Alertf(format string, args ...interface{}) error {
+	return w.logf(LOG_ALERT, format, args)
+}
+
+func (w *Flog) Critf(format string, args ...interface{}) error {
+	return w.logf(LOG_CRIT, format, args)
+}
+
+func (w *Flog) Errf(format string, args ...interface{}) error {
+	return w.logf(LOG_ERR, format, args)
+}
+
+func (w *Flog) Warningf(format string, args ...interface{}) error {
+	return w.logf(LOG_WARNING, format, args)
+}
+
+func (w *Flog) Noticef(format string, args ...interface{}) error {
+	return w.logf(LOG_NOTICE, format, args)
+}
+
+func (w *Flog) Infof(format string, args ...interface{}) error {
+	return w.logf(LOG_INFO, format, args)
+}
+
+func (w *Flog) Debugf(format string, args ...interface{}) error {
+	return w.logf(LOG_DEBUG, format, args)
+}
+
+func (e *Entry) Emergf(format string, args ...interface{}) error {
+	return e.logf(LOG_EMERG, format, args)
+}
+
+func (e *Entry) Alertf(format string, args ...interface{}) error {
+	return e.logf(LOG_ALERT, format, args)
+}
+
+func (e *Entry) Critf(format string, args ...interface{}) error {
+	return e.logf(LOG_CRIT, format, args)
+}
+
+func (e *Entry) Errf(format string, args ...interface{}) error {
+	return e.logf(LOG_ERR, format, args)
+}
+
+func (e *Entry) Warningf(format string, args ...interface{}) error {
+	return e.logf(LOG_WARNING, format, args)
+}
+
+func (e *Entry) Noticef(format string, args ...interface{}) error {
+	return e.logf(LOG_NOTICE, format, args)
+}
+
+func (e *Entry) Infof(format string, args ...interface{}) error {
+	return e.logf(LOG_INFO, format, args)
+}
+
+func (e *Entry) Debugf(format string, args ...interface{}) error {
+	return e.logf(LOG_DEBUG, format, args)
+}
+
+// formatWriter 给任意Writer加上printf风格的方法
+type formatWriter struct {
+	Writer
+}
+
+// NewFormatWriter 返回带有Infof等方法的w，如 NewFormatWriter(syslogWriter)。
+// w是*Flog、*Entry这类知道过滤级别的Writer时，被过滤的日志不会格式化
+func NewFormatWriter(w Writer) FormatWriter {
+	if fw, ok := w.(FormatWriter); ok {
+		return fw
+	}
+	return formatWriter{w}
+}
+
+func (f formatWriter) logf(p Priority, format string, args []interface{}) error {
+	if e, ok := f.Writer.(interface{ enabled(Priority) bool }); ok && !e.enabled(p) {
+		return nil
+	}
+	return writeTo(f.Writer, p, fmt.Sprintf(format, args...))
+}
+
+func (f formatWriter) Emergf(format string, args ...interface{}) error {
+	return f.logf(LOG_EMERG, format, args)
+}
+
+func (f formatWriter) Alertf(format string, args ...interface{}) error {
+	return f.logf(LOG_ALERT, format, args)
+}
+
+func (f formatWriter) Critf(format string, args ...interface{}) error {
+	return f.logf(LOG_CRIT, format, args)
+}
+
+func (f formatWriter) Errf(format string, args ...interface{}) error {
+	return f.logf(LOG_ERR, format, args)
+}
+
+func (f formatWriter) Warningf(format string, args ...interface{}) error {
+	return f.logf(LOG_WARNING, format, args)
+}
+
+func (f formatWriter) Noticef(format string, args ...interface{}) error {
+	return f.logf(LOG_NOTICE, format, args)
+}
+
+func (f formatWriter) Infof(format string, args ...interface{}) error {
+	return f.logf(LOG_INFO, format, args)
+}
+
+func (f formatWriter) Debugf(format string, args ...interface{}) error {
+	return f.logf(LOG_DEBUG, format, args)
+}
@@ -0,0 +1,106 @@
+package flog
+
+import (
+	"bytes"
+	"testing"
+)
+
+type countingStringer struct {
+	n *int
+}
+
+func (s countingStringer) String() string {
+	*s.n++
+	return "counted"
+}
+
+func Test_printf(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestFlog(&buf, LOG_LOCAL0|LOG_NOTICE, "test")
+	l.SetFormatter(CompactFormatter{})
+
+	var _ FormatWriter = l
+	var _ FormatWriter = l.WithTTL(0)
+
+	n := 0
+	l.Debugf("debug %v", countingStringer{&n})
+	l.Infof("info %v", countingStringer{&n})
+	l.WithTTL(0).Debugf("debug %v", countingStringer{&n})
+	if n != 0 {
+		t.Errorf("Expect: filtered args not formatted, get:%d", n)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Expect: empty, get:%q", buf.String())
+	}
+
+	l.Noticef("notice %d %v", 1, countingStringer{&n})
+	l.WithTTL(0).Errf("err %s", "x")
+	if n != 1 {
+		t.Errorf("Expect:1, get:%d", n)
+	}
+	if s := buf.String(); s != "5|notice 1 counted\n3|err x ttl=0\n" {
+		t.Errorf("Expect:%q, get:%q", "5|notice 1 counted\n3|err x ttl=0\n", s)
+	}
+}
+
+func Test_formatWriter(t *testing.T) {
+	var a, b bytes.Buffer
+	la := newTestFlog(&a, LOG_LOCAL0|LOG_INFO, "test")
+	lb := newTestFlog(&b, LOG_LOCAL0|LOG_ERR, "test")
+	la.SetFormatter(CompactFormatter{})
+	lb.SetFormatter(CompactFormatter{})
+
+	if NewFormatWriter(la) != FormatWriter(la) {
+		t.Errorf("Expect: FormatWriter returned as is")
+	}
+
+	w := NewFormatWriter(MultiWriter(la, lb))
+
+	n := 0
+	w.Debugf("debug %v", countingStringer{&n})
+	if n != 0 {
+		t.Errorf("Expect: filtered args not formatted, get:%d", n)
+	}
+
+	w.Infof("info %v", countingStringer{&n})
+	w.Errf("err %d", 1)
+	if n != 1 {
+		t.Errorf("Expect:1, get:%d", n)
+	}
+	if s := a.String(); s != "6|info counted\n3|err 1\n" {
+		t.Errorf("Expect:%q, get:%q", "6|info counted\n3|err 1\n", s)
+	}
+	if s := b.String(); s != "3|err 1\n" {
+		t.Errorf("Expect:%q, get:%q", "3|err 1\n", s)
+	}
+
+	// 不知道过滤级别的Writer总是格式化
+	var c bytes.Buffer
+	w = NewFormatWriter(&writerOnly{&c})
+	w.Warningf("x=%d", 2)
+	if s := c.String(); s != "warning x=2" {
+		t.Errorf("Expect:%q, get:%q", "warning x=2", s)
+	}
+}
+
+// writerOnly 只实现Writer，记录最后一次调用
+type writerOnly struct {
+	buf *bytes.Buffer
+}
+
+func (w *writerOnly) log(p string, m string) error {
+	w.buf.Reset()
+	w.buf.WriteString(p + " " + m)
+	return nil
+}
+
+func (w *writerOnly) Write(b []byte) (int, error) { return len(b), w.log("write", string(b)) }
+func (w *writerOnly) Close() error                { return nil }
+func (w *writerOnly) Emerg(m string) error        { return w.log("emerg", m) }
+func (w *writerOnly) Alert(m string) error        { return w.log("alert", m) }
+func (w *writerOnly) Crit(m string) error         { return w.log("crit", m) }
+func (w *writerOnly) Err(m string) error          { return w.log("err", m) }
+func (w *writerOnly) Warning(m string) error      { return w.log("warning", m) }
+func (w *writerOnly) Notice(m string) error       { return w.log("notice", m) }
+func (w *writerOnly) Info(m string) error         { return w.log("info", m) }
+func (w *writerOnly) Debug(m string) error        { return w.log("debug", m) }
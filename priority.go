@@ -0,0 +1,32 @@
+package flog
+
+var facilityNames = [...]string{
+	"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news",
+	"uucp", "cron", "authpriv", "ftp", "", "", "", "",
+	"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+}
+
+var severityNames = [...]string{
+	"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug",
+}
+
+var severityLabels = [...]string{
+	"EMERG", "ALERT", "CRIT", "ERR", "WARNING", "NOTICE", "INFO", "DEBUG",
+}
+
+func (p Priority) facilityName() string {
+	f := int(p&facilityMask) >> 3
+	if f < len(facilityNames) {
+		return facilityNames[f]
+	}
+	return ""
+}
+
+func (p Priority) severityName() string {
+	return severityNames[p&severityMask]
+}
+
+// String 返回与New的priority参数相同的形式，如 "local0:info"
+func (p Priority) String() string {
+	return p.facilityName() + ":" + p.severityName()
+}
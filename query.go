@@ -0,0 +1,257 @@
+package flog
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 至少两个字符的scheme后跟 :// 才认为是URL，
+// 这样 C:\logs\app.log、C:/logs/app.log 这样的Windows盘符路径仍是文件
+var schemeRegexp = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]+://`)
+
+// dsn 是解析后的New的filename
+type dsn struct {
+	// file、stderr、stdout、syslog(本机syslog)、journald，
+	// 或网络tcp、tcp4、tcp6、udp、udp4、udp6、tls、unix、unixgram
+	scheme string
+	// 文件路径或网络地址
+	addr  string
+	level string
+	tag   string
+	opts  []Option
+}
+
+// isFile 报告d是否写入文件
+func (d *dsn) isFile() bool {
+	return d.scheme == "file"
+}
+
+// isNet 报告d是否写入网络
+func (d *dsn) isNet() bool {
+	switch d.scheme {
+	case "file", "stderr", "stdout", "syslog", "journald":
+		return false
+	}
+	return true
+}
+
+// parseDSN 解析New的filename，可以是URL：
+//
+//	file:///var/log/app.log   文件，也可以直接写路径 /var/log/app.log
+//	stderr://  stdout://      也可以写 <stderr>、<stdout>，空字符串是stderr
+//	syslog://                 本机syslog，也可以写 <syslog>
+//	syslog://host[:514]       同udp://host:514
+//	journald://               systemd journal，也可以写 <journald>，见DialJournal
+//	tcp://host:514  udp://host:514  tls://host:6514  unix:///dev/log  unixgram:///dev/log
+//
+// 后面可以带参数，如 file:///var/log/app.log?level=debug&rotate=daily&maxsize=100MB：
+//
+//	level      级别，同New的priority，如local0:debug，优先于New的参数
+//	tag        tag，优先于New的参数
+//	format     输出格式：human、syslog、compact、json、rfc5424、console
+//	caller     为true时记录调用位置，见SetReportCaller
+//	tsformat   时间格式，见SetTimeFormat，可以是layout或预设的stamp、stampmilli、
+//	           stampmicro、rfc3339、rfc3339nano、datetime、rfc3339nano-utc
+//	tz         时区，如UTC、Local、Asia/Shanghai，见SetTimeLocation
+//
+// 以下参数只对文件有效：
+//
+//	rotate     daily或hourly，文件名改为 app-%Y-%m-%d.log 或 app-%Y-%m-%d-%H.log，
+//	           文件名中已经有%Y等时不改
+//	maxsize    见WithMaxSize，可以带单位，见ParseBytes
+//	maxbackups 见WithMaxBackups
+//	maxtotal   见WithMaxTotalSize，可以带单位
+//	compress   见WithCompress
+//	buffer     缓存大小，可以带单位，见WithBuffer
+//	flush      开启buffer时定期写出的间隔，如1s
+func parseDSN(s string) (*dsn, error) {
+	d := new(dsn)
+
+	var query string
+	if schemeRegexp.MatchString(s) {
+		u, err := url.Parse(s)
+		if err != nil {
+			return nil, err
+		}
+		query = u.RawQuery
+
+		d.scheme = strings.ToLower(u.Scheme)
+		switch d.scheme {
+		case "file":
+			d.addr = filePath(u)
+		case "stderr", "stdout", "journald":
+		case "syslog":
+			if u.Host != "" {
+				d.scheme, d.addr = "udp", u.Host
+				if u.Port() == "" {
+					d.addr = net.JoinHostPort(u.Hostname(), "514")
+				}
+			}
+		case "unix", "unixgram":
+			d.addr = u.Path
+		case "tcp", "tcp4", "tcp6", "udp", "udp4", "udp6", "tls":
+			d.addr = u.Host
+		default:
+			return nil, fmt.Errorf("flog: unknown scheme %q", u.Scheme)
+		}
+	} else {
+		var name string
+		name, query, _ = strings.Cut(s, "?")
+
+		switch name {
+		case "", "<stderr>":
+			d.scheme = "stderr"
+		case "<stdout>":
+			d.scheme = "stdout"
+		case "<syslog>":
+			d.scheme = "syslog"
+		case "<journald>":
+			d.scheme = "journald"
+		default:
+			d.scheme, d.addr = "file", name
+		}
+	}
+
+	if query == "" {
+		return d, nil
+	}
+	if err := d.parseQuery(query); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// filePath 返回file://的路径，file://app.log是相对路径，
+// file:///C:/logs/app.log是Windows路径C:/logs/app.log
+func filePath(u *url.URL) string {
+	p := u.Host + u.Path
+	if len(p) >= 3 && p[0] == '/' && p[2] == ':' && ('a' <= p[1]|0x20 && p[1]|0x20 <= 'z') {
+		p = p[1:]
+	}
+	return p
+}
+
+func (d *dsn) parseQuery(query string) error {
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var buffer int
+	var flush time.Duration
+
+	// 按key排序，tz在tsformat之后，可以覆盖预设的时区
+	for _, k := range keys {
+		v := values[k][len(values[k])-1]
+
+		switch k {
+		case "level":
+			if _, err := log_level(v); err != nil {
+				return err
+			}
+			d.level = v
+		case "tag":
+			d.tag = v
+		case "format":
+			f, err := formatterByName(v)
+			if err != nil {
+				return err
+			}
+			d.opts = append(d.opts, func(w *Flog) { w.SetFormatter(f) })
+		case "rotate":
+			switch v {
+			case "daily":
+				d.addr = d.datedName("-%Y-%m-%d")
+			case "hourly":
+				d.addr = d.datedName("-%Y-%m-%d-%H")
+			default:
+				return fmt.Errorf("flog: unknown rotate %q", v)
+			}
+		case "maxsize":
+			n, err := ParseBytes(v)
+			if err != nil {
+				return err
+			}
+			d.opts = append(d.opts, WithMaxSize(int64(n)))
+		case "maxtotal":
+			n, err := ParseBytes(v)
+			if err != nil {
+				return err
+			}
+			d.opts = append(d.opts, WithMaxTotalSize(int64(n)))
+		case "maxbackups":
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("flog: invalid maxbackups %q", v)
+			}
+			d.opts = append(d.opts, WithMaxBackups(n))
+		case "compress":
+			on, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("flog: invalid compress %q", v)
+			}
+			d.opts = append(d.opts, WithCompress(on))
+		case "buffer":
+			n, err := ParseBytes(v)
+			if err != nil {
+				return err
+			}
+			buffer = int(n)
+		case "flush":
+			flush, err = time.ParseDuration(v)
+			if err != nil {
+				return fmt.Errorf("flog: invalid flush %q", v)
+			}
+		case "tsformat":
+			layout, loc := timePreset(v)
+			d.opts = append(d.opts, WithTimeFormat(layout))
+			if loc != nil {
+				d.opts = append(d.opts, WithTimeLocation(loc))
+			}
+		case "caller":
+			on, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("flog: invalid caller %q", v)
+			}
+			if on {
+				d.opts = append(d.opts, WithCaller(0))
+			}
+		case "tz":
+			loc, err := time.LoadLocation(v)
+			if err != nil {
+				return fmt.Errorf("flog: invalid tz %q", v)
+			}
+			d.opts = append(d.opts, WithTimeLocation(loc))
+		default:
+			return fmt.Errorf("flog: unknown option %q", k)
+		}
+	}
+
+	if buffer > 0 {
+		d.opts = append(d.opts, WithBuffer(buffer, flush))
+	}
+	return nil
+}
+
+// datedName 在文件名的扩展名前插入日期格式，不是文件时不改
+func (d *dsn) datedName(stamp string) string {
+	name := d.addr
+	if !d.isFile() || strings.ContainsRune(name, '%') {
+		return name
+	}
+	ext := filepath.Ext(name)
+	return strings.TrimSuffix(name, ext) + stamp + ext
+}
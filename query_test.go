@@ -0,0 +1,127 @@
+package flog
+
+import (
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func Test_dsnQuery(t *testing.T) {
+	tests := map[string]string{
+		"app.log":                       "app.log",
+		"/var/log/app.log?rotate=daily": "/var/log/app-%Y-%m-%d.log",
+		"app?rotate=hourly":             "app-%Y-%m-%d-%H",
+		"app-%Y.log?rotate=daily":       "app-%Y.log",
+		"app.log?maxsize=1MB":           "app.log",
+	}
+	for in, expect := range tests {
+		d, err := parseDSN(in)
+		if err != nil || d.addr != expect {
+			t.Errorf("%q Expect:%s, get:%+v %v", in, expect, d, err)
+		}
+	}
+
+	for _, in := range []string{"app.log?rotate=weekly", "app.log?maxsize=big", "app.log?maxbackups=x", "app.log?compress=maybe", "app.log?color=1",
+		"app.log?level=nope", "app.log?format=xml", "app.log?buffer=1x", "app.log?flush=soon", "ftp://host/app.log"} {
+		if _, err := parseDSN(in); err == nil {
+			t.Errorf("%q Expect: error", in)
+		}
+	}
+}
+
+func Test_newQuery(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.log")
+
+	w, err := New(file+"?maxsize=20&maxbackups=1", "local0:info", "test", WithFormat(FormatCompact))
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	for i := 0; i < 5; i++ {
+		w.Info("line-" + strconv.Itoa(i))
+	}
+	w.Close()
+
+	if s := readFile(t, file); s != "6|line-4\n" {
+		t.Errorf("Expect:%q, get:%q", "6|line-4\n", s)
+	}
+	if s := readFile(t, file+".1"); s != "6|line-2\n6|line-3\n" {
+		t.Errorf("Expect:%q, get:%q", "6|line-2\n6|line-3\n", s)
+	}
+	if exists(file + ".2") {
+		t.Errorf("Expect: only one backup")
+	}
+}
+
+func Test_parseDSN(t *testing.T) {
+	tests := []struct {
+		in     string
+		scheme string
+		addr   string
+		level  string
+		tag    string
+		opts   int
+	}{
+		{"", "stderr", "", "", "", 0},
+		{"<stderr>", "stderr", "", "", "", 0},
+		{"<stdout>", "stdout", "", "", "", 0},
+		{"<syslog>", "syslog", "", "", "", 0},
+		{"/var/log/app.log", "file", "/var/log/app.log", "", "", 0},
+		{"app.log", "file", "app.log", "", "", 0},
+		{"./logs/app+1.log", "file", "./logs/app+1.log", "", "", 0},
+		{`C:\logs\app.log`, "file", `C:\logs\app.log`, "", "", 0},
+		{"C:/logs/app.log", "file", "C:/logs/app.log", "", "", 0},
+		{"c://logs/app.log", "file", "c://logs/app.log", "", "", 0},
+		{"file:///var/log/app.log?level=debug&tag=web", "file", "/var/log/app.log", "debug", "web", 0},
+		{"file://app.log?rotate=daily", "file", "app-%Y-%m-%d.log", "", "", 0},
+		{"file:///C:/logs/app.log", "file", "C:/logs/app.log", "", "", 0},
+		{"FILE:///tmp/a%20b.log", "file", "/tmp/a b.log", "", "", 0},
+		{"stderr://?format=json&level=warning", "stderr", "", "warning", "", 1},
+		{"stdout://", "stdout", "", "", "", 0},
+		{"syslog://", "syslog", "", "", "", 0},
+		{"<journald>", "journald", "", "", "", 0},
+		{"journald://?tag=app", "journald", "", "", "app", 0},
+		{"syslog://?tag=app", "syslog", "", "", "app", 0},
+		{"syslog://10.0.0.1", "udp", "10.0.0.1:514", "", "", 0},
+		{"syslog://[::1]:1514", "udp", "[::1]:1514", "", "", 0},
+		{"tcp://localhost:514", "tcp", "localhost:514", "", "", 0},
+		{"udp://10.0.0.1:514?format=rfc5424", "udp", "10.0.0.1:514", "", "", 1},
+		{"tcp4://[::1]:514", "tcp4", "[::1]:514", "", "", 0},
+		{"tls://logs.example.com:6514", "tls", "logs.example.com:6514", "", "", 0},
+		{"unix:///dev/log", "unix", "/dev/log", "", "", 0},
+		{"unixgram:///var/run/syslog", "unixgram", "/var/run/syslog", "", "", 0},
+		{"app.log?buffer=64KB&flush=1s&maxsize=10MB", "file", "app.log", "", "", 2},
+	}
+
+	for _, tt := range tests {
+		d, err := parseDSN(tt.in)
+		if err != nil {
+			t.Errorf("%s Expect:nil, get:%v", tt.in, err)
+			continue
+		}
+		if d.scheme != tt.scheme || d.addr != tt.addr || d.level != tt.level || d.tag != tt.tag || len(d.opts) != tt.opts {
+			t.Errorf("%s Expect:%s %s %s %s %d, get:%s %s %s %s %d", tt.in, tt.scheme, tt.addr, tt.level, tt.tag, tt.opts,
+				d.scheme, d.addr, d.level, d.tag, len(d.opts))
+		}
+	}
+}
+
+func Test_openDSN(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.log")
+
+	w, err := Open("file://" + filepath.ToSlash(file) + "?level=local1:notice&tag=web&format=compact&buffer=4KB")
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	l := w.(*Flog)
+	if p := l.getPriority(); p != LOG_LOCAL1|LOG_NOTICE || l.tag != "web" || l.opts.buffer != 4096 {
+		t.Errorf("Expect: local1:notice web 4096, get:%v %s %d", p, l.tag, l.opts.buffer)
+	}
+	l.Info("filtered")
+	l.Notice("hello")
+	l.Close()
+
+	if s := readFile(t, file); s != "5|hello\n" {
+		t.Errorf("Expect:%q, get:%q", "5|hello\n", s)
+	}
+}
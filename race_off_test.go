@@ -0,0 +1,5 @@
+//go:build !race
+
+package flog
+
+const raceEnabled = false
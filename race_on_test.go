@@ -0,0 +1,6 @@
+//go:build race
+
+package flog
+
+// -race会额外分配内存，分配次数的检查不适用
+const raceEnabled = true
@@ -0,0 +1,61 @@
+package flog
+
+import (
+	"math"
+	"time"
+)
+
+// 每秒速率的指数加权移动平均，时间常数与uptime的1分钟负载类似取5秒
+var rateAlpha = 1 - math.Exp(-1.0/5)
+
+type ewma struct {
+	start time.Time
+	count float64
+	rate  float64
+	ready bool
+}
+
+func (e *ewma) tick(now time.Time) {
+	if e.start.IsZero() {
+		e.start = now
+		return
+	}
+
+	n := int64(now.Sub(e.start) / time.Second)
+	if n <= 0 {
+		return
+	}
+
+	if e.ready {
+		e.rate += rateAlpha * (e.count - e.rate)
+	} else {
+		e.rate = e.count
+		e.ready = true
+	}
+
+	if n > 1 {
+		e.rate *= math.Pow(1-rateAlpha, float64(n-1))
+	}
+
+	e.count = 0
+	e.start = e.start.Add(time.Duration(n) * time.Second)
+}
+
+func (e *ewma) add(now time.Time) {
+	e.tick(now)
+	e.count++
+}
+
+func (e *ewma) value(now time.Time) float64 {
+	e.tick(now)
+	return e.rate
+}
+
+// RateEstimate 返回该级别日志每秒写入条数的估计值(EWMA)，
+// 被级别过滤掉的日志不计入
+func (w *Flog) RateEstimate(p Priority) float64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.rates[p&severityMask].value(w.now())
+}
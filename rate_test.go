@@ -0,0 +1,37 @@
+package flog
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+)
+
+func Test_rateEstimate(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestFlog(&buf, LOG_LOCAL0|LOG_DEBUG, "test")
+
+	c := &fakeClock{t: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	l.now = c.now
+
+	// 每秒20条，持续1分钟
+	for i := 0; i < 60*20; i++ {
+		l.Info("rate")
+		c.add(50 * time.Millisecond)
+	}
+
+	r := l.RateEstimate(LOG_INFO)
+	if math.Abs(r-20) > 1 {
+		t.Errorf("Expect:~20, get:%v", r)
+	}
+
+	if r := l.RateEstimate(LOG_ERR); r != 0 {
+		t.Errorf("Expect:0, get:%v", r)
+	}
+
+	// 停止写入后估计值应衰减
+	c.add(30 * time.Second)
+	if r2 := l.RateEstimate(LOG_INFO); r2 >= r/10 {
+		t.Errorf("Expect:<%v, get:%v", r/10, r2)
+	}
+}
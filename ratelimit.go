@@ -0,0 +1,110 @@
+package flog
+
+import (
+	"strconv"
+	"time"
+)
+
+// rateWindow 是SetRateLimit计数的时间窗口
+var rateWindow = time.Second
+
+type rateLimit struct {
+	n          int
+	start      time.Time
+	count      int
+	suppressed int
+	// 窗口结束时写出被丢弃的条数
+	timer *time.Timer
+}
+
+// SetRateLimit 限制severity级别的日志每秒最多写入n条，超出的丢弃，
+// 并在这一秒结束时(或该级别的下一条日志写入前、Close时)写一条 "suppressed N messages"，
+// 该级别有路由时发给路由。n<=0时取消限制
+func (w *Flog) SetRateLimit(severity Priority, n int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	p := severity & severityMask
+	if rl := w.limits[p]; rl != nil && rl.timer != nil {
+		rl.timer.Stop()
+	}
+	if n <= 0 {
+		w.limits[p] = nil
+		return
+	}
+	w.limits[p] = &rateLimit{n: n}
+}
+
+// allow 报告这条日志能否写入，能写入时返回之前被丢弃的条数的日志(没有时为nil)，
+// 由调用方在这条日志之前写出。调用时必须持有mu
+func (w *Flog) allow(p Priority) (bool, *Record) {
+	rl := w.limits[p&severityMask]
+	if rl == nil {
+		return true, nil
+	}
+
+	now := w.now()
+	if now.Sub(rl.start) >= rateWindow {
+		rl.start = now
+		rl.count = 0
+	}
+
+	if rl.count >= rl.n {
+		rl.suppressed++
+		if rl.timer == nil {
+			rl.timer = time.AfterFunc(rl.start.Add(rateWindow).Sub(now), func() {
+				w.onRateWindow(p)
+			})
+		}
+		return false, nil
+	}
+	rl.count++
+
+	return true, w.suppressedRecord(p)
+}
+
+// suppressedRecord 返回p级别被丢弃条数的日志并清零，没有时返回nil。调用时必须持有mu
+func (w *Flog) suppressedRecord(p Priority) *Record {
+	rl := w.limits[p&severityMask]
+	if rl == nil || rl.suppressed == 0 {
+		return nil
+	}
+
+	n := rl.suppressed
+	rl.suppressed = 0
+	if rl.timer != nil {
+		rl.timer.Stop()
+		rl.timer = nil
+	}
+	return &Record{Priority: (w.priority & facilityMask) | p&severityMask, Tag: w.tag, Msg: "suppressed " + strconv.Itoa(n) + " messages"}
+}
+
+// suppressedTargets 返回被丢弃条数的日志r的路由，没有路由时直接写入r。调用时必须持有mu
+func (w *Flog) suppressedTargets(r *Record) []Writer {
+	if len(w.routes) > 0 {
+		if targets := w.routeTargets(r.Priority); len(targets) > 0 {
+			return targets
+		}
+	}
+	w.write(r)
+	return nil
+}
+
+// onRateWindow 在窗口结束时写出p级别被丢弃的条数
+func (w *Flog) onRateWindow(p Priority) {
+	w.mu.Lock()
+	if rl := w.limits[p&severityMask]; rl != nil {
+		rl.timer = nil
+	}
+	r := w.suppressedRecord(p)
+	if r == nil || w.closed {
+		w.mu.Unlock()
+		return
+	}
+	targets := w.suppressedTargets(r)
+	w.mu.Unlock()
+
+	if len(targets) > 0 {
+		w.dispatch(targets, r)
+	}
+}
@@ -0,0 +1,113 @@
+package flog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_rateLimit(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestFlog(&buf, LOG_LOCAL0|LOG_DEBUG, "test")
+	l.SetFormatter(CompactFormatter{})
+	c := &fakeClock{t: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	l.now = c.now
+
+	l.SetRateLimit(LOG_ERR, 2)
+	for i := 0; i < 5; i++ {
+		l.Err("loop")
+		l.Info("info")
+	}
+
+	c.add(time.Second)
+	l.Err("next second")
+
+	expect := "3|loop\n6|info\n3|loop\n6|info\n6|info\n6|info\n6|info\n" +
+		"3|suppressed 3 messages\n3|next second\n"
+	if buf.String() != expect {
+		t.Errorf("Expect:%q, get:%q", expect, buf.String())
+	}
+
+	// Close时写出剩余的条数
+	buf.Reset()
+	l.Err("again")
+	l.Err("dropped")
+	l.Close()
+	if s := buf.String(); s != "3|again\n3|suppressed 1 messages\n" {
+		t.Errorf("Expect:%q, get:%q", "3|again\n3|suppressed 1 messages\n", s)
+	}
+}
+
+func Test_rateLimitOff(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestFlog(&buf, LOG_LOCAL0|LOG_DEBUG, "test")
+	l.SetFormatter(CompactFormatter{})
+
+	l.SetRateLimit(LOG_ERR, 1)
+	l.SetRateLimit(LOG_ERR, 0)
+	l.Err("a")
+	l.Err("b")
+
+	if s := buf.String(); s != "3|a\n3|b\n" {
+		t.Errorf("Expect:%q, get:%q", "3|a\n3|b\n", s)
+	}
+}
+
+func Test_rateLimitWindow(t *testing.T) {
+	old := rateWindow
+	rateWindow = 20 * time.Millisecond
+	defer func() { rateWindow = old }()
+
+	var buf syncBuffer
+	l := newFlog(nopCloser{&buf}, LOG_LOCAL0|LOG_DEBUG, "test")
+	l.noclose = true
+	l.SetFormatter(CompactFormatter{})
+	l.SetRateLimit(LOG_ERR, 1)
+
+	l.Err("first")
+	l.Err("dropped")
+	l.Err("dropped")
+
+	// 窗口结束时写出，不用等下一条日志
+	deadline := time.Now().Add(2 * time.Second)
+	for !strings.Contains(buf.String(), "suppressed") && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if s := buf.String(); s != "3|first\n3|suppressed 2 messages\n" {
+		t.Errorf("Expect:%q, get:%q", "3|first\n3|suppressed 2 messages\n", s)
+	}
+
+	l.Close()
+	if s := buf.String(); s != "3|first\n3|suppressed 2 messages\n" {
+		t.Errorf("Expect: summary written once, get:%q", s)
+	}
+}
+
+func Test_rateLimitRoute(t *testing.T) {
+	var main, routed bytes.Buffer
+	l := newTestFlog(&main, LOG_LOCAL0|LOG_DEBUG, "test")
+	l.SetFormatter(CompactFormatter{})
+	c := &fakeClock{t: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	l.now = c.now
+
+	sink := newTestFlog(&routed, LOG_LOCAL0|LOG_DEBUG, "test")
+	sink.SetFormatter(CompactFormatter{})
+	l.SetSeverityRoute(LOG_ERR, sink)
+	l.SetRateLimit(LOG_ERR, 1)
+
+	l.Err("a")
+	l.Err("b")
+	c.add(time.Second)
+	l.Err("c")
+	l.Err("d")
+	l.Close()
+
+	if main.Len() != 0 {
+		t.Errorf("Expect: nothing in main output, get:%q", main.String())
+	}
+	expect := "3|a\n3|suppressed 1 messages\n3|c\n3|suppressed 1 messages\n"
+	if s := routed.String(); s != expect {
+		t.Errorf("Expect:%q, get:%q", expect, s)
+	}
+}
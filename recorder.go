@@ -0,0 +1,84 @@
+package flog
+
+import (
+	"sync"
+	"time"
+)
+
+// Recorded 是Recorder记录的一条日志
+type Recorded struct {
+	Severity Priority
+	Tag      string
+	Time     time.Time
+	Msg      string
+	Fields   []Field
+}
+
+// Recorder 把写出的日志保存在内存中，用于在单元测试中检查日志，
+// 不需要解析临时文件或stderr。只记录通过级别过滤、中间件和SetRateLimit的日志
+type Recorder struct {
+	*Flog
+
+	mu      sync.Mutex
+	entries []Recorded
+}
+
+// NewRecorder 创建级别为local0:debug的Recorder，opts同New
+func NewRecorder(opts ...Option) *Recorder {
+	r := new(Recorder)
+	r.Flog = newFlog(discard{}, LOG_LOCAL0|LOG_DEBUG, "")
+	r.Flog.recorder = r
+	r.apply(opts)
+	r.start()
+	return r
+}
+
+func (r *Recorder) add(rec *Record) {
+	e := Recorded{
+		Severity: rec.Priority & severityMask,
+		Tag:      rec.Tag,
+		Time:     rec.Time,
+		Msg:      rec.Msg,
+	}
+	if len(rec.Fields) > 0 {
+		e.Fields = append([]Field(nil), rec.Fields...)
+	}
+
+	r.mu.Lock()
+	r.entries = append(r.entries, e)
+	r.mu.Unlock()
+}
+
+// Entries 返回记录的日志，按写出的顺序
+func (r *Recorder) Entries() []Recorded {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Recorded(nil), r.entries...)
+}
+
+// LastEntry 返回最后一条日志，没有时ok为false
+func (r *Recorder) LastEntry() (e Recorded, ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.entries) == 0 {
+		return e, false
+	}
+	return r.entries[len(r.entries)-1], true
+}
+
+// Reset 清空记录的日志
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	r.entries = nil
+	r.mu.Unlock()
+}
+
+type discard struct{}
+
+func (discard) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (discard) Close() error {
+	return nil
+}
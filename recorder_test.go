@@ -0,0 +1,47 @@
+package flog
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_recorder(t *testing.T) {
+	r := NewRecorder()
+	r.SetTag("app")
+	t0 := time.Date(2020, 3, 4, 5, 6, 7, 0, time.UTC)
+	r.now = (&fakeClock{t: t0}).now
+
+	var w Writer = r
+	w.Debug("debug")
+	r.With("user", "bob").Err("failed")
+
+	entries := r.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("Expect: 2 entries, get:%v", entries)
+	}
+	if e := entries[0]; e.Severity != LOG_DEBUG || e.Msg != "debug" || e.Tag != "app" || !e.Time.Equal(t0) {
+		t.Errorf("Expect: debug entry, get:%+v", e)
+	}
+
+	e, ok := r.LastEntry()
+	if !ok || e.Severity != LOG_ERR || e.Msg != "failed" || len(e.Fields) != 1 || e.Fields[0] != (Field{"user", "bob"}) {
+		t.Errorf("Expect: err entry with user, get:%+v %v", e, ok)
+	}
+
+	r.Reset()
+	if _, ok := r.LastEntry(); ok || len(r.Entries()) != 0 {
+		t.Errorf("Expect: empty after Reset")
+	}
+
+	// 被过滤的日志不记录
+	r.SetLevel("warning")
+	r.Info("filtered")
+	r.Warning("kept")
+	if entries := r.Entries(); len(entries) != 1 || entries[0].Msg != "kept" {
+		t.Errorf("Expect: only kept, get:%+v", entries)
+	}
+
+	if err := r.Close(); err != nil {
+		t.Errorf("Expect:nil, get:%v", err)
+	}
+}
@@ -0,0 +1,127 @@
+package flog
+
+import (
+	"regexp"
+	"strings"
+)
+
+type redactRule struct {
+	re   *regexp.Regexp
+	old  string
+	repl string
+}
+
+func (r *redactRule) apply(s string) string {
+	if r.re != nil {
+		return r.re.ReplaceAllString(s, r.repl)
+	}
+	return strings.ReplaceAll(s, r.old, r.repl)
+}
+
+// AddRedactPattern 把消息、字段值和SD参数中匹配re的部分替换为replacement，
+// replacement中可以用$1引用分组，同regexp.ReplaceAllString。
+// 非字符串的字段值(error、fmt.Stringer、[]byte等)按输出时的文本匹配，替换后变为字符串。
+// 在中间件和调用位置之后执行，所有输出(包括路由、崩溃缓存)中都不会出现原文。
+// re为nil时忽略
+func (w *Flog) AddRedactPattern(re *regexp.Regexp, replacement string) {
+	if re == nil {
+		return
+	}
+	w.addRedact(redactRule{re: re, repl: replacement})
+}
+
+// AddRedactString 同AddRedactPattern，替换的是固定的字符串s
+func (w *Flog) AddRedactString(s, replacement string) {
+	if s == "" {
+		return
+	}
+	w.addRedact(redactRule{old: s, repl: replacement})
+}
+
+func (w *Flog) addRedact(r redactRule) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var rules []redactRule
+	if old := w.redacts.Load(); old != nil {
+		rules = append(rules, *old...)
+	}
+	rules = append(rules, r)
+	w.redacts.Store(&rules)
+}
+
+func redactString(rules []redactRule, s string) string {
+	for i := range rules {
+		s = rules[i].apply(s)
+	}
+	return s
+}
+
+// redactValue 返回脱敏后的字段值，没有变化时返回false
+func redactValue(rules []redactRule, v interface{}) (string, bool) {
+	var s string
+	switch v := v.(type) {
+	case nil, bool, int, int64, uint64, float64:
+		return "", false
+	case []byte:
+		s = string(v)
+	default:
+		s = fieldString(v)
+	}
+
+	r := redactString(rules, s)
+	return r, r != s
+}
+
+// redactSD 返回脱敏后的SD元素，没有变化时返回sd本身
+func redactSD(rules []redactRule, sd []SDElement) []SDElement {
+	out := sd
+	for i, e := range sd {
+		var params map[string]string
+		for k, v := range e.Params {
+			r := redactString(rules, v)
+			if r == v {
+				continue
+			}
+			if params == nil {
+				params = make(map[string]string, len(e.Params))
+				for k1, v1 := range e.Params {
+					params[k1] = v1
+				}
+			}
+			params[k] = r
+		}
+		if params == nil {
+			continue
+		}
+
+		// 不修改调用方的SD
+		if &out[0] == &sd[0] {
+			out = append([]SDElement(nil), sd...)
+		}
+		out[i].Params = params
+	}
+	return out
+}
+
+func redact(rules []redactRule, r *Record) {
+	r.Msg = redactString(rules, r.Msg)
+	if len(r.SD) > 0 {
+		r.SD = redactSD(rules, r.SD)
+	}
+
+	copied := false
+	for i, f := range r.Fields {
+		v, ok := redactValue(rules, f.Value)
+		if !ok {
+			continue
+		}
+
+		// 不修改调用方的字段
+		if !copied {
+			r.Fields = append([]Field(nil), r.Fields...)
+			copied = true
+		}
+		r.Fields[i].Value = v
+	}
+}
@@ -0,0 +1,85 @@
+package flog
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func Test_redact(t *testing.T) {
+	var buf, errs bytes.Buffer
+	l := newTestFlog(&buf, LOG_LOCAL0|LOG_INFO, "test")
+	l.SetFormatter(CompactFormatter{})
+	route := newTestFlog(&errs, LOG_LOCAL0|LOG_INFO, "test")
+	route.SetFormatter(CompactFormatter{})
+	l.SetSeverityRoute(LOG_ERR, route)
+
+	l.AddRedactPattern(regexp.MustCompile(`(Bearer) [A-Za-z0-9._-]+`), "$1 ***")
+	l.AddRedactPattern(regexp.MustCompile(`\b(\d{4})[ -]?\d{4}[ -]?\d{4}[ -]?(\d{4})\b`), "$1-****-****-$2")
+	l.AddRedactString("hunter2", "***")
+
+	fields := []Field{{"password", "hunter2"}, {"n", 1}}
+	l.log(LOG_INFO, "auth: Bearer abc.def-123 card 4111 1111 1111 1234", fields)
+	l.Err("db password hunter2 rejected")
+
+	if s := buf.String(); s != "6|auth: Bearer *** card 4111-****-****-1234 password=*** n=1\n" {
+		t.Errorf("Expect: redacted, get:%q", s)
+	}
+	if s := errs.String(); s != "3|db password *** rejected\n" {
+		t.Errorf("Expect: redacted in route, get:%q", s)
+	}
+	if fields[0].Value != "hunter2" {
+		t.Errorf("Expect: caller fields unchanged, get:%v", fields[0].Value)
+	}
+	if strings.Contains(buf.String()+errs.String(), "hunter2") {
+		t.Errorf("Expect: no secret in output")
+	}
+}
+
+type secretStringer string
+
+func (s secretStringer) String() string { return "token=" + string(s) }
+
+func Test_redactRendered(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestFlog(&buf, LOG_LOCAL0|LOG_INFO, "test")
+	l.SetFormatter(CompactFormatter{})
+	l.AddRedactString("hunter2", "***")
+	l.AddRedactPattern(nil, "x")
+
+	// 中间件添加的字段也会脱敏
+	l.Use(func(p Priority, msg string, fields []Field) (Priority, string, []Field, bool) {
+		return p, msg, append(fields, Field{"mw", "hunter2"}), false
+	})
+
+	secret := []byte("hunter2")
+	l.log(LOG_INFO, "m", []Field{
+		{"err", errors.New("bad hunter2")},
+		{"s", secretStringer("hunter2")},
+		{"b", secret},
+		{"n", 1},
+	})
+	expect := `6|m err="bad ***" s="token=***" b=*** n=1 mw=***` + "\n"
+	if s := buf.String(); s != expect {
+		t.Errorf("Expect:%q, get:%q", expect, s)
+	}
+	if string(secret) != "hunter2" {
+		t.Errorf("Expect: caller bytes unchanged, get:%q", secret)
+	}
+
+	buf.Reset()
+	f := NewRFC5424Formatter()
+	f.Hostname = "h"
+	l.SetFormatter(f)
+	params := map[string]string{"pw": "hunter2"}
+	l.SetDefaultSD("def@1", map[string]string{"k": "hunter2"})
+	l.WithSD("req@1", params).Info("sd")
+	if s := buf.String(); strings.Contains(s, "hunter2") || !strings.Contains(s, `[def@1 k="***"][req@1 pw="***"]`) {
+		t.Errorf("Expect: SD redacted, get:%q", s)
+	}
+	if params["pw"] != "hunter2" {
+		t.Errorf("Expect: caller SD unchanged, get:%v", params)
+	}
+}
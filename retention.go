@@ -0,0 +1,95 @@
+package flog
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// WithMaxTotalSize 轮转后按修改时间从旧到新删除备份(包括.gz和按日期切换前的文件)，
+// 直到备份的总大小不超过size字节，当前正在写的文件不计入
+func WithMaxTotalSize(size int64) Option {
+	return func(w *Flog) {
+		w.opts.maxTotal = size
+	}
+}
+
+// backups 返回当前文件的所有备份，压缩中的临时文件和.gz的索引除外
+func (fw *fileWriter) backups() []string {
+	glob := fw.name + ".*"
+	if fw.pattern != "" {
+		glob = strings.NewReplacer("%Y", "*", "%m", "*", "%d", "*", "%H", "*").Replace(fw.pattern) + "*"
+	}
+
+	files, _ := filepath.Glob(glob)
+	out := files[:0]
+	for _, f := range files {
+		if f != fw.name && !strings.HasSuffix(f, ".tmp") && !strings.HasSuffix(f, ".idx") {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// retainDated 按日期切换时只保留MaxBackups个最新的旧文件，
+// 文件名中的日期按字典序即为时间顺序，.gz与未压缩的同名文件算作一个
+func (fw *fileWriter) retainDated() {
+	seen := make(map[string]bool)
+	var names []string
+	for _, f := range fw.backups() {
+		f = strings.TrimSuffix(f, ".gz")
+		if !seen[f] {
+			seen[f] = true
+			names = append(names, f)
+		}
+	}
+	sort.Strings(names)
+
+	for len(names) > fw.opts.maxBackups {
+		os.Remove(names[0])
+		os.Remove(names[0] + ".gz")
+		os.Remove(names[0] + ".gz.idx")
+		names = names[1:]
+	}
+}
+
+// retain 删除超出MaxBackups(按日期切换时)和MaxTotalSize的旧备份，调用时必须持有cmu
+func (fw *fileWriter) retain() {
+	if fw.pattern != "" && fw.opts.maxBackups > 0 {
+		fw.retainDated()
+	}
+	if fw.opts.maxTotal <= 0 {
+		return
+	}
+
+	type backup struct {
+		name string
+		fi   os.FileInfo
+	}
+
+	var bs []backup
+	var total int64
+	for _, name := range fw.backups() {
+		fi, err := os.Stat(name)
+		if err != nil || !fi.Mode().IsRegular() {
+			continue
+		}
+		bs = append(bs, backup{name, fi})
+		total += fi.Size()
+	}
+
+	sort.Slice(bs, func(i, j int) bool {
+		return bs[i].fi.ModTime().Before(bs[j].fi.ModTime())
+	})
+
+	for _, b := range bs {
+		if total <= fw.opts.maxTotal {
+			break
+		}
+		if os.Remove(b.name) == nil {
+			total -= b.fi.Size()
+			os.Remove(b.name + ".idx")
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package flog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_maxTotalSize(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.log")
+
+	l, err := File(file, LOG_LOCAL0|LOG_INFO, "test", WithMaxSize(100), WithMaxTotalSize(250))
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	l.SetFormatter(CompactFormatter{})
+
+	// 每个备份约100字节，修改时间依次递增
+	line := strings.Repeat("x", 97)
+	for i := 0; i < 6; i++ {
+		l.Info(line)
+		if i > 0 {
+			mt := time.Now().Add(-time.Duration(10-i) * time.Second)
+			os.Chtimes(file+".1", mt, mt)
+		}
+	}
+	l.Close()
+
+	var total int64
+	for _, f := range l.file.backups() {
+		fi, _ := os.Stat(f)
+		total += fi.Size()
+	}
+	if total > 250 {
+		t.Errorf("Expect: <=250 bytes of backups, get:%d", total)
+	}
+	if !exists(file+".1") || !exists(file+".2") || exists(file+".3") {
+		t.Errorf("Expect: newest two backups kept, get:%v", l.file.backups())
+	}
+	if s := readFile(t, file); s != "6|"+line+"\n" {
+		t.Errorf("Expect: current file kept, get:%q", s)
+	}
+}
+
+func Test_maxTotalSizeDaily(t *testing.T) {
+	dir := t.TempDir()
+
+	for i, day := range []string{"01", "02", "03"} {
+		name := filepath.Join(dir, "app-2020-01-"+day+".log.gz")
+		os.WriteFile(name, make([]byte, 100), 0666)
+		mt := time.Date(2020, 1, 1+i, 23, 0, 0, 0, time.UTC)
+		os.Chtimes(name, mt, mt)
+	}
+
+	l, err := File(filepath.Join(dir, "app-%Y-%m-%d.log"), LOG_LOCAL0|LOG_INFO, "test", WithLocation(time.UTC), WithMaxTotalSize(150))
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	l.SetFormatter(CompactFormatter{})
+
+	c := &fakeClock{t: time.Date(2020, 1, 4, 12, 0, 0, 0, time.UTC)}
+	l.now = c.now
+	l.Info("day4")
+	l.Close()
+
+	if exists(filepath.Join(dir, "app-2020-01-01.log.gz")) || exists(filepath.Join(dir, "app-2020-01-02.log.gz")) {
+		t.Errorf("Expect: oldest days removed")
+	}
+	if !exists(filepath.Join(dir, "app-2020-01-03.log.gz")) {
+		t.Errorf("Expect: newest day kept")
+	}
+	if s := readFile(t, filepath.Join(dir, "app-2020-01-04.log")); s != "6|day4\n" {
+		t.Errorf("Expect:%q, get:%q", "6|day4\n", s)
+	}
+}
@@ -0,0 +1,150 @@
+package flog
+
+import (
+	"os"
+	"sort"
+	"strconv"
+)
+
+// SDElement 是RFC5424的一个STRUCTURED-DATA元素，如 [origin@32473 software="app"]
+type SDElement struct {
+	ID     string
+	Params map[string]string
+}
+
+// SetDefaultSD 设置附加到每条RFC5424日志上的SD元素，与消息自带的同ID元素合并
+// (消息的参数优先)。params为nil时删除该元素
+func (w *Flog) SetDefaultSD(id string, params map[string]string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var old []SDElement
+	if p := w.sd.Load(); p != nil {
+		old = *p
+	}
+
+	sd := make([]SDElement, 0, len(old)+1)
+	for _, e := range old {
+		if e.ID != id {
+			sd = append(sd, e)
+		}
+	}
+	if params != nil {
+		sd = append(sd, SDElement{id, params})
+	}
+	if len(sd) == 0 {
+		w.sd.Store(nil)
+		return
+	}
+	w.sd.Store(&sd)
+}
+
+// WithSD 返回一个日志，其消息带有给定的SD元素
+func (w *Flog) WithSD(id string, params map[string]string) *Entry {
+	return (&Entry{l: w}).WithSD(id, params)
+}
+
+func (e *Entry) WithSD(id string, params map[string]string) *Entry {
+	n := e.with()
+	n.sd = mergeSD(e.sd, []SDElement{{id, params}})
+	return n
+}
+
+// mergeSD 合并两组SD元素，同ID的元素参数合并，b中的参数优先
+func mergeSD(a, b []SDElement) []SDElement {
+	out := make([]SDElement, 0, len(a)+len(b))
+	out = append(out, a...)
+
+	for _, e := range b {
+		i := 0
+		for ; i < len(out); i++ {
+			if out[i].ID == e.ID {
+				break
+			}
+		}
+		if i == len(out) {
+			out = append(out, e)
+			continue
+		}
+
+		params := make(map[string]string, len(out[i].Params)+len(e.Params))
+		for k, v := range out[i].Params {
+			params[k] = v
+		}
+		for k, v := range e.Params {
+			params[k] = v
+		}
+		out[i] = SDElement{e.ID, params}
+	}
+	return out
+}
+
+// RFC5424Formatter 输出RFC5424格式：
+// "<pri>1 timestamp hostname app-name procid msgid [sd] msg"
+type RFC5424Formatter struct {
+	Hostname string
+	MsgID    string
+}
+
+func NewRFC5424Formatter() *RFC5424Formatter {
+	host, _ := os.Hostname()
+	return &RFC5424Formatter{Hostname: host}
+}
+
+func (f *RFC5424Formatter) Format(b []byte, r *Record) []byte {
+	b = append(b, '<')
+	b = strconv.AppendInt(b, int64(r.Priority), 10)
+	b = append(b, ">1 "...)
+	b = r.Time.AppendFormat(b, "2006-01-02T15:04:05.000000Z07:00")
+	b = append(b, ' ')
+	b = appendNilValue(b, f.Hostname)
+	b = append(b, ' ')
+	b = appendNilValue(b, r.Tag)
+	b = append(b, ' ')
+	b = strconv.AppendInt(b, int64(r.Pid), 10)
+	b = append(b, ' ')
+	b = appendNilValue(b, f.MsgID)
+	b = append(b, ' ')
+	b = appendSD(b, r.SD)
+	return appendMsg(b, " ", r.Msg, r.Fields)
+}
+
+func appendNilValue(b []byte, s string) []byte {
+	if s == "" {
+		return append(b, '-')
+	}
+	return append(b, s...)
+}
+
+func appendSD(b []byte, sd []SDElement) []byte {
+	if len(sd) == 0 {
+		return append(b, '-')
+	}
+
+	for _, e := range sd {
+		b = append(b, '[')
+		b = append(b, e.ID...)
+
+		keys := make([]string, 0, len(e.Params))
+		for k := range e.Params {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			b = append(b, ' ')
+			b = append(b, k...)
+			b = append(b, '=', '"')
+			v := e.Params[k]
+			for i := 0; i < len(v); i++ {
+				if c := v[i]; c == '"' || c == '\\' || c == ']' {
+					b = append(b, '\\')
+				}
+				b = append(b, v[i])
+			}
+			b = append(b, '"')
+		}
+		b = append(b, ']')
+	}
+	return b
+}
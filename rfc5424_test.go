@@ -0,0 +1,74 @@
+package flog
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func Test_rfc5424DefaultSD(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestFlog(&buf, LOG_LOCAL0|LOG_INFO, "app")
+
+	f := NewRFC5424Formatter()
+	f.Hostname = "host1"
+	l.SetFormatter(f)
+	l.SetDefaultSD("origin@32473", map[string]string{"software": "app", "swVersion": "1.0"})
+
+	l.Info("plain")
+	l.WithSD("origin@32473", map[string]string{"swVersion": "1.1"}).
+		WithSD("req@32473", map[string]string{"id": `a"b]`}).
+		Info("merged")
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expect:2 lines, get:%q", buf.String())
+	}
+
+	re := regexp.MustCompile(`^<134>1 \d{4}-\d\d-\d\dT\d\d:\d\d:\d\d\.\d{6}\S+ host1 app \d+ - (.*)$`)
+
+	m := re.FindStringSubmatch(lines[0])
+	if m == nil || m[1] != `[origin@32473 software="app" swVersion="1.0"] plain` {
+		t.Errorf("Expect: default SD, get:%q", lines[0])
+	}
+
+	m = re.FindStringSubmatch(lines[1])
+	expect := `[origin@32473 software="app" swVersion="1.1"][req@32473 id="a\"b\]"] merged`
+	if m == nil || m[1] != expect {
+		t.Errorf("Expect:%q, get:%q", expect, lines[1])
+	}
+
+	buf.Reset()
+	l.SetDefaultSD("origin@32473", nil)
+	l.Info("none")
+	if !strings.HasSuffix(buf.String(), " - - none\n") {
+		t.Errorf("Expect: nil SD, get:%q", buf.String())
+	}
+}
+
+func Test_rfc5424DefaultSDConcurrent(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestFlog(&buf, LOG_LOCAL0|LOG_INFO, "app")
+	l.SetFormatter(NewRFC5424Formatter())
+	// 崩溃缓存在加锁之前格式化
+	l.ring = newRing(16)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			l.SetDefaultSD("a@1", map[string]string{"i": strconv.Itoa(i)})
+		}
+	}()
+	for i := 0; i < 1000; i++ {
+		l.Info("msg")
+	}
+	<-done
+	l.Info("last")
+
+	if s := buf.String(); !strings.Contains(s, `[a@1 i="999"] last`) {
+		t.Errorf("Expect: default SD, get:%q", s)
+	}
+}
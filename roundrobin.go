@@ -0,0 +1,147 @@
+package flog
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// 写入失败的目标在这段时间内不再参与轮询
+const roundRobinCooldown = 30 * time.Second
+
+type roundRobin struct {
+	mu       sync.Mutex
+	ws       []Writer
+	down     []time.Time
+	next     int
+	now      func() time.Time
+	cooldown time.Duration
+}
+
+// RoundRobin 把日志轮流分发给writers(如多个syslog Dial)，用于分摊负载。
+// 写入失败的目标暂时跳过，由下一个目标重写这条日志，冷却后重新参与轮询
+func RoundRobin(writers ...Writer) Writer {
+	return &roundRobin{
+		ws:       writers,
+		down:     make([]time.Time, len(writers)),
+		now:      time.Now,
+		cooldown: roundRobinCooldown,
+	}
+}
+
+// pick 选出从next开始第一个可用的目标，全部不可用时返回-1
+func (r *roundRobin) pick(skip []bool) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	for i := 0; i < len(r.ws); i++ {
+		idx := (r.next + i) % len(r.ws)
+		if skip[idx] || now.Before(r.down[idx]) {
+			continue
+		}
+		r.next = (idx + 1) % len(r.ws)
+		return idx
+	}
+	return -1
+}
+
+func (r *roundRobin) markDown(idx int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.down[idx] = r.now().Add(r.cooldown)
+}
+
+func (r *roundRobin) do(f func(Writer) error) error {
+	if len(r.ws) == 0 {
+		return nil
+	}
+
+	var errs []error
+	tried := make([]bool, len(r.ws))
+
+	for {
+		idx := r.pick(tried)
+		if idx < 0 {
+			break
+		}
+		tried[idx] = true
+
+		err := f(r.ws[idx])
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+		r.markDown(idx)
+	}
+
+	// 全部处于冷却时仍然逐个尝试，不丢弃日志
+	if len(errs) == 0 {
+		for i, w := range r.ws {
+			err := f(w)
+			if err == nil {
+				r.mu.Lock()
+				r.down[i] = time.Time{}
+				r.mu.Unlock()
+				return nil
+			}
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (r *roundRobin) Write(b []byte) (int, error) {
+	err := r.do(func(w Writer) error {
+		_, err := w.Write(b)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func (r *roundRobin) Close() error {
+	var errs []error
+	for _, w := range r.ws {
+		if err := w.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *roundRobin) Emerg(m string) error {
+	return r.do(func(w Writer) error { return w.Emerg(m) })
+}
+
+func (r *roundRobin) Alert(m string) error {
+	return r.do(func(w Writer) error { return w.Alert(m) })
+}
+
+func (r *roundRobin) Crit(m string) error {
+	return r.do(func(w Writer) error { return w.Crit(m) })
+}
+
+func (r *roundRobin) Err(m string) error {
+	return r.do(func(w Writer) error { return w.Err(m) })
+}
+
+func (r *roundRobin) Warning(m string) error {
+	return r.do(func(w Writer) error { return w.Warning(m) })
+}
+
+func (r *roundRobin) Notice(m string) error {
+	return r.do(func(w Writer) error { return w.Notice(m) })
+}
+
+func (r *roundRobin) Info(m string) error {
+	return r.do(func(w Writer) error { return w.Info(m) })
+}
+
+func (r *roundRobin) Debug(m string) error {
+	return r.do(func(w Writer) error { return w.Debug(m) })
+}
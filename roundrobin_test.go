@@ -0,0 +1,85 @@
+package flog
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type failWriter struct {
+	buf  bytes.Buffer
+	fail bool
+}
+
+func (f *failWriter) Write(p []byte) (int, error) {
+	if f.fail {
+		return 0, errors.New("endpoint down")
+	}
+	return f.buf.Write(p)
+}
+
+func (f *failWriter) Close() error {
+	return nil
+}
+
+func (f *failWriter) lines() int {
+	n := strings.Count(f.buf.String(), "\n")
+	f.buf.Reset()
+	return n
+}
+
+func Test_roundRobin(t *testing.T) {
+	var ends [3]*failWriter
+	var ws []Writer
+	for i := range ends {
+		ends[i] = new(failWriter)
+		l := newFlog(ends[i], LOG_LOCAL0|LOG_INFO, "test")
+		ws = append(ws, l)
+	}
+
+	rr := RoundRobin(ws...)
+	c := &fakeClock{t: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	rr.(*roundRobin).now = c.now
+
+	send := func(n int) {
+		for i := 0; i < n; i++ {
+			if err := rr.Info("spread"); err != nil {
+				t.Fatalf("Expect:nil, get:%v", err)
+			}
+		}
+	}
+
+	send(300)
+	for i, e := range ends {
+		if n := e.lines(); n != 100 {
+			t.Errorf("endpoint %d Expect:100, get:%d", i, n)
+		}
+	}
+
+	ends[1].fail = true
+	send(300)
+	if n := ends[0].lines() + ends[2].lines(); n != 300 {
+		t.Errorf("Expect:300 on live endpoints, get:%d", n)
+	}
+	ends[1].fail = false
+	if n := ends[1].lines(); n != 0 {
+		t.Errorf("Expect: dead endpoint skipped, get:%d", n)
+	}
+
+	c.add(roundRobinCooldown)
+	send(300)
+	for i, e := range ends {
+		if n := e.lines(); n < 90 || n > 110 {
+			t.Errorf("endpoint %d Expect:~100 after recovery, get:%d", i, n)
+		}
+	}
+
+	for _, e := range ends {
+		e.fail = true
+	}
+	if err := rr.Info("lost"); err == nil {
+		t.Errorf("Expect: error when all endpoints fail")
+	}
+}
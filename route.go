@@ -0,0 +1,94 @@
+package flog
+
+import (
+	"errors"
+	"sort"
+)
+
+type RouteMode int
+
+const (
+	// RouteMostSpecific 日志只发给阈值最接近它级别的一个路由
+	RouteMostSpecific RouteMode = iota
+	// RouteAll 日志发给所有阈值不低于它级别的路由
+	RouteAll
+)
+
+type route struct {
+	min Priority
+	w   Writer
+}
+
+// SetSeverityRoute 把级别不低于minSeverity(数值不大于)的日志发给w，
+// w为nil时删除该路由。有路由匹配的日志不再写入自身的输出，
+// 没有匹配的仍写入自身的输出
+func (w *Flog) SetSeverityRoute(minSeverity Priority, out Writer) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	minSeverity &= severityMask
+
+	routes := make([]route, 0, len(w.routes)+1)
+	for _, r := range w.routes {
+		if r.min != minSeverity {
+			routes = append(routes, r)
+		}
+	}
+	if out != nil {
+		routes = append(routes, route{minSeverity, out})
+	}
+
+	sort.Slice(routes, func(i, j int) bool {
+		return routes[i].min < routes[j].min
+	})
+	w.routes = routes
+}
+
+func (w *Flog) SetRouteMode(m RouteMode) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.routeMode = m
+}
+
+// routeTargets 调用时必须持有mu
+func (w *Flog) routeTargets(p Priority) []Writer {
+	tp := p & severityMask
+
+	var out []Writer
+	for _, r := range w.routes {
+		if r.min < tp {
+			continue
+		}
+		out = append(out, r.w)
+		if w.routeMode == RouteMostSpecific {
+			break
+		}
+	}
+	return out
+}
+
+func (w *Flog) dispatch(targets []Writer, r *Record) (int, error) {
+	var errs []error
+	for _, t := range targets {
+		var err error
+		if l, ok := t.(*Flog); ok {
+			_, err = l.logRecord(&Record{
+				Priority: r.Priority,
+				Msg:      r.Msg,
+				Fields:   r.Fields,
+				SD:       r.SD,
+			})
+		} else {
+			err = writeTo(t, r.Priority, r.Msg)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return 0, err
+	}
+	return len(r.Msg), nil
+}
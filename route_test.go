@@ -0,0 +1,69 @@
+package flog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func Test_severityRoute(t *testing.T) {
+	var main, stdout, file, hook bytes.Buffer
+
+	l := newTestFlog(&main, LOG_LOCAL0|LOG_DEBUG, "test")
+	sinks := map[string]*bytes.Buffer{"stdout": &stdout, "file": &file, "hook": &hook}
+
+	newSink := func(b *bytes.Buffer) *Flog {
+		s := newTestFlog(b, LOG_LOCAL0|LOG_DEBUG, "test")
+		s.SetFormatter(CompactFormatter{})
+		return s
+	}
+	l.SetSeverityRoute(LOG_DEBUG, newSink(&stdout))
+	l.SetSeverityRoute(LOG_WARNING, newSink(&file))
+	l.SetSeverityRoute(LOG_CRIT, newSink(&hook))
+
+	got := func() string {
+		var s []string
+		for _, name := range []string{"stdout", "file", "hook"} {
+			if sinks[name].Len() > 0 {
+				s = append(s, name)
+			}
+			sinks[name].Reset()
+		}
+		return strings.Join(s, ",")
+	}
+
+	tests := []struct {
+		mode   RouteMode
+		p      Priority
+		expect string
+	}{
+		{RouteMostSpecific, LOG_DEBUG, "stdout"},
+		{RouteMostSpecific, LOG_INFO, "stdout"},
+		{RouteMostSpecific, LOG_WARNING, "file"},
+		{RouteMostSpecific, LOG_ERR, "file"},
+		{RouteMostSpecific, LOG_CRIT, "hook"},
+		{RouteMostSpecific, LOG_EMERG, "hook"},
+		{RouteAll, LOG_INFO, "stdout"},
+		{RouteAll, LOG_WARNING, "stdout,file"},
+		{RouteAll, LOG_ALERT, "stdout,file,hook"},
+	}
+
+	for _, tt := range tests {
+		l.SetRouteMode(tt.mode)
+		writeTo(l, tt.p, "routed")
+		if s := got(); s != tt.expect {
+			t.Errorf("mode %d severity %d Expect:%s, get:%s", tt.mode, tt.p, tt.expect, s)
+		}
+	}
+
+	if main.Len() != 0 {
+		t.Errorf("Expect: routed lines not in main sink, get:%q", main.String())
+	}
+
+	l.SetSeverityRoute(LOG_DEBUG, nil)
+	l.SetRouteMode(RouteMostSpecific)
+	l.Info("unrouted")
+	if !strings.Contains(main.String(), "unrouted") || got() != "" {
+		t.Errorf("Expect: unmatched line in main sink, get:%q", main.String())
+	}
+}
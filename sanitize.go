@@ -0,0 +1,68 @@
+package flog
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const hexDigits = "0123456789abcdef"
+
+// terminalSafe 把控制字符(保留\n和\t)、C1控制字符和非法UTF-8字节
+// 转义成可见的 \xNN 形式，使ESC开头的ANSI序列在终端中失效
+func terminalSafe(s string) string {
+	i := 0
+	for ; i < len(s); i++ {
+		c := s[i]
+		if c < 0x20 && c != '\n' && c != '\t' || c >= 0x7f {
+			break
+		}
+	}
+	if i == len(s) {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s) + 8)
+	b.WriteString(s[:i])
+
+	for i < len(s) {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			escapeByte(&b, s[i])
+		case r == '\n' || r == '\t':
+			b.WriteByte(byte(r))
+		case r < 0x20 || r == 0x7f:
+			escapeByte(&b, byte(r))
+		case r >= 0x80 && r <= 0x9f:
+			escapeByte(&b, byte(r))
+		default:
+			b.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+
+	return b.String()
+}
+
+func escapeByte(b *strings.Builder, c byte) {
+	b.WriteString(`\x`)
+	b.WriteByte(hexDigits[c>>4])
+	b.WriteByte(hexDigits[c&0x0f])
+}
+
+// cleanTag 把tag中影响 "tag[pid]:" 格式解析的字符替换成 '_'
+func cleanTag(tag string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '[' || r == ']' || r == ':':
+			return '_'
+		case r <= ' ' || r == 0x7f || r >= 0x80 && r <= 0x9f:
+			return '_'
+		case unicode.IsSpace(r):
+			return '_'
+		}
+		return r
+	}, tag)
+}
@@ -0,0 +1,90 @@
+package flog
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func Test_terminalSafe(t *testing.T) {
+	tests := []struct {
+		in     string
+		expect string
+	}{
+		{"plain 中文", "plain 中文"},
+		{"a\x1b[2Jb", `a\x1b[2Jb`},
+		{"line1\nline2\tx", "line1\nline2\tx"},
+		{"over\rwrite", `over\x0dwrite`},
+		{"bell\x07del\x7f", `bell\x07del\x7f`},
+		{"c1\u009b2J", `c1\x9b2J`},
+		{"bad\x9b2J", `bad\x9b2J`},
+	}
+
+	for _, tt := range tests {
+		if s := terminalSafe(tt.in); s != tt.expect {
+			t.Errorf("Expect:%q, get:%q", tt.expect, s)
+		}
+	}
+}
+
+func Test_setTerminalSafe(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestFlog(&buf, LOG_LOCAL0|LOG_INFO, "test")
+	l.SetTerminalSafe(true)
+
+	l.Info("clear \x1b[2J screen")
+
+	if strings.Contains(buf.String(), "\x1b") {
+		t.Errorf("Expect: no ESC, get:%q", buf.String())
+	}
+	if !strings.Contains(buf.String(), `clear \x1b[2J screen`) {
+		t.Errorf("Expect: escaped sequence, get:%q", buf.String())
+	}
+}
+
+func Test_setTerminalSafeConcurrent(t *testing.T) {
+	var buf syncBuffer
+	l := newFlog(nopCloser{&buf}, LOG_LOCAL0|LOG_INFO, "test")
+	l.noclose = true
+	c := NewChannelWriter(l, 16)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			l.SetTerminalSafe(i%2 == 0)
+		}
+	}()
+
+	for i := 0; i < 200; i++ {
+		c.Info("esc \x1b[2J")
+		l.Info("esc \x1b[2J")
+	}
+	<-done
+	c.Close()
+}
+
+func Test_cleanTag(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestFlog(&buf, LOG_LOCAL0|LOG_INFO, "app[v2]")
+	l.Info("hello")
+
+	ok, _ := regexp.MatchString(`^\S+ +\S+ \S+ INFO ([^\[\]: ]+)\[\d+\]: hello\n$`, buf.String())
+	if !ok {
+		t.Errorf("Expect: parseable framing, get:%q", buf.String())
+	}
+	if l.tag != "app_v2_" {
+		t.Errorf("Expect:app_v2_, get:%s", l.tag)
+	}
+
+	l.SetTag("my app: 1")
+	if l.tag != "my_app__1" {
+		t.Errorf("Expect:my_app__1, get:%s", l.tag)
+	}
+
+	l.SetTag("应用-1.0")
+	if l.tag != "应用-1.0" {
+		t.Errorf("Expect:应用-1.0, get:%s", l.tag)
+	}
+}
@@ -0,0 +1,128 @@
+package flog
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MalformedLine 是Scanner无法解析的行
+type MalformedLine struct {
+	Line int
+	Text string
+	Err  error
+}
+
+// Scanner 解析本包写出的文本日志(SyslogFormatter和HumanFormatter的格式)。
+// time.Stamp不含年份，解析出的Time年份为0；HumanFormatter的格式不含facility，
+// 解析出的Priority只有级别部分
+type Scanner struct {
+	s         *bufio.Scanner
+	rec       Record
+	line      int
+	malformed []MalformedLine
+}
+
+func NewScanner(r io.Reader) *Scanner {
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 1024*1024)
+	return &Scanner{s: s}
+}
+
+// Scan 读取下一条可以解析的日志，无法解析的行记录到Malformed中
+func (s *Scanner) Scan() bool {
+	for s.s.Scan() {
+		s.line++
+
+		text := s.s.Text()
+		r, err := parseLine(text)
+		if err != nil {
+			s.malformed = append(s.malformed, MalformedLine{s.line, text, err})
+			continue
+		}
+
+		s.rec = r
+		return true
+	}
+	return false
+}
+
+func (s *Scanner) Record() Record {
+	return s.rec
+}
+
+func (s *Scanner) Malformed() []MalformedLine {
+	return s.malformed
+}
+
+func (s *Scanner) Err() error {
+	return s.s.Err()
+}
+
+var errMalformed = errors.New("flog: malformed line")
+
+func parseLine(line string) (Record, error) {
+	var r Record
+	rest := line
+	hasPri := false
+
+	if strings.HasPrefix(rest, "<") {
+		i := strings.IndexByte(rest, '>')
+		if i < 0 {
+			return r, errMalformed
+		}
+		p, err := strconv.Atoi(rest[1:i])
+		if err != nil || p < 0 || p > int(LOG_LOCAL7|LOG_DEBUG) {
+			return r, errMalformed
+		}
+		r.Priority = Priority(p)
+		rest = rest[i+1:]
+		hasPri = true
+	}
+
+	if len(rest) < len(time.Stamp)+1 || rest[len(time.Stamp)] != ' ' {
+		return r, errMalformed
+	}
+	t, err := time.Parse(time.Stamp, rest[:len(time.Stamp)])
+	if err != nil {
+		return r, err
+	}
+	r.Time = t
+	rest = rest[len(time.Stamp)+1:]
+
+	if !hasPri {
+		i := strings.IndexByte(rest, ' ')
+		if i < 0 {
+			return r, errMalformed
+		}
+		sev := -1
+		for n, label := range severityLabels {
+			if label == rest[:i] {
+				sev = n
+			}
+		}
+		if sev < 0 {
+			return r, errMalformed
+		}
+		r.Priority = Priority(sev)
+		rest = rest[i+1:]
+	}
+
+	i := strings.IndexByte(rest, '[')
+	j := strings.Index(rest, "]:")
+	if i < 0 || j < i || strings.ContainsAny(rest[:i], " ]") {
+		return r, errMalformed
+	}
+	pid, err := strconv.Atoi(rest[i+1 : j])
+	if err != nil {
+		return r, errMalformed
+	}
+	r.Tag = rest[:i]
+	r.Pid = pid
+	r.Msg = strings.TrimPrefix(rest[j+2:], " ")
+
+	return r, nil
+}
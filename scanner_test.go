@@ -0,0 +1,54 @@
+package flog
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func Test_scanner(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestFlog(&buf, LOG_DAEMON|LOG_DEBUG, "app")
+	c := &fakeClock{t: time.Date(0, 3, 4, 5, 6, 7, 0, time.UTC)}
+	l.now = c.now
+
+	l.Err("disk error")
+	buf.WriteString("garbage line\n")
+	l.SetFormatter(SyslogFormatter{})
+	l.Debug("中文 message: with colon")
+	l.Info("")
+
+	s := NewScanner(&buf)
+
+	var recs []Record
+	for s.Scan() {
+		recs = append(recs, s.Record())
+	}
+	if err := s.Err(); err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+
+	expect := []struct {
+		p   Priority
+		msg string
+	}{
+		{LOG_ERR, "disk error"},
+		{LOG_DAEMON | LOG_DEBUG, "中文 message: with colon"},
+		{LOG_DAEMON | LOG_INFO, ""},
+	}
+
+	if len(recs) != len(expect) {
+		t.Fatalf("Expect:%d records, get:%d", len(expect), len(recs))
+	}
+	for i, e := range expect {
+		r := recs[i]
+		if r.Priority != e.p || r.Msg != e.msg || r.Tag != "app" || r.Pid <= 0 || !r.Time.Equal(c.t) {
+			t.Errorf("record %d Expect:%v %q, get:%+v", i, e.p, e.msg, r)
+		}
+	}
+
+	m := s.Malformed()
+	if len(m) != 1 || m[0].Line != 2 || m[0].Text != "garbage line" {
+		t.Errorf("Expect: line 2 malformed, get:%+v", m)
+	}
+}
@@ -0,0 +1,147 @@
+package flog
+
+import (
+	"sync"
+)
+
+// Scope 缓存一组日志(如一次请求内的日志)，EndScope时一次性写出，
+// 使这组日志在输出中连续，不与其它goroutine的日志交错
+type Scope struct {
+	l     *Flog
+	mu    sync.Mutex
+	recs  []*Record
+	ended bool
+}
+
+func (w *Flog) BeginScope() *Scope {
+	return &Scope{l: w}
+}
+
+// EndScope 在一次加锁中写出缓存的日志，之后的日志直接写入。
+// 缓存的日志同样经过限流、路由、统计和钩子
+func (s *Scope) EndScope() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ended {
+		return nil
+	}
+	s.ended = true
+
+	if len(s.recs) == 0 {
+		return nil
+	}
+	recs := s.recs
+	s.recs = nil
+
+	type routed struct {
+		targets    []Writer
+		suppressed *Record
+	}
+
+	l := s.l
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return ErrClosed
+	}
+
+	var err error
+	oks := make([]bool, len(recs))
+	routes := make([]routed, len(recs))
+	for i, r := range recs {
+		targets, suppressed, ok, err1 := l.outputLocked(r, nil)
+		if err == nil {
+			err = err1
+		}
+		oks[i] = ok
+		routes[i] = routed{targets, suppressed}
+	}
+	l.mu.Unlock()
+
+	hooks := l.hooks.Load()
+	for i, r := range recs {
+		if rt := routes[i]; rt.targets != nil {
+			if rt.suppressed != nil {
+				l.dispatch(rt.targets, rt.suppressed)
+			}
+			if _, err1 := l.dispatch(rt.targets, r); err == nil {
+				err = err1
+			}
+		}
+		if oks[i] && hooks != nil {
+			l.fire(*hooks, r)
+		}
+	}
+	return err
+}
+
+// Close 等同于EndScope，不会关闭所属的日志
+func (s *Scope) Close() error {
+	return s.EndScope()
+}
+
+func (s *Scope) Write(b []byte) (int, error) {
+	return s.writeAndRetry(s.l.getPriority(), string(b))
+}
+
+func (s *Scope) Emerg(m string) (err error) {
+	_, err = s.writeAndRetry(LOG_EMERG, m)
+	return err
+}
+
+func (s *Scope) Alert(m string) (err error) {
+	_, err = s.writeAndRetry(LOG_ALERT, m)
+	return err
+}
+
+func (s *Scope) Crit(m string) (err error) {
+	_, err = s.writeAndRetry(LOG_CRIT, m)
+	return err
+}
+
+func (s *Scope) Err(m string) (err error) {
+	_, err = s.writeAndRetry(LOG_ERR, m)
+	return err
+}
+
+func (s *Scope) Warning(m string) (err error) {
+	_, err = s.writeAndRetry(LOG_WARNING, m)
+	return err
+}
+
+func (s *Scope) Notice(m string) (err error) {
+	_, err = s.writeAndRetry(LOG_NOTICE, m)
+	return err
+}
+
+func (s *Scope) Info(m string) (err error) {
+	_, err = s.writeAndRetry(LOG_INFO, m)
+	return err
+}
+
+func (s *Scope) Debug(m string) (err error) {
+	_, err = s.writeAndRetry(LOG_DEBUG, m)
+	return err
+}
+
+func (s *Scope) writeAndRetry(p Priority, m string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ended {
+		return s.l.writeAndRetry(p, m)
+	}
+
+	l := s.l
+	r := &Record{Priority: p, Msg: m}
+	if !l.resolve(r) {
+		return 0, nil
+	}
+
+	// 时间取写日志时而不是EndScope时
+	r.Time = l.now()
+	s.recs = append(s.recs, r)
+
+	return len(m), nil
+}
@@ -0,0 +1,99 @@
+package flog
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_scope(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestFlog(&buf, LOG_LOCAL0|LOG_INFO, "test")
+	l.SetFormatter(CompactFormatter{})
+
+	var wg sync.WaitGroup
+	for _, name := range []string{"a", "b"} {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+
+			s := l.BeginScope()
+			for i := 0; i < 50; i++ {
+				s.Info(name + strconv.Itoa(i))
+				l.Notice("other")
+			}
+			s.Debug(name + " filtered")
+			s.EndScope()
+		}(name)
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 200 {
+		t.Fatalf("Expect:200 lines, get:%d", len(lines))
+	}
+
+	for _, name := range []string{"a", "b"} {
+		start := -1
+		for i, line := range lines {
+			if line == "6|"+name+"0" {
+				start = i
+				break
+			}
+		}
+		if start < 0 {
+			t.Fatalf("scope %s not found", name)
+		}
+
+		for i := 0; i < 50; i++ {
+			expect := "6|" + name + strconv.Itoa(i)
+			if lines[start+i] != expect {
+				t.Errorf("Expect:%q at %d, get:%q", expect, start+i, lines[start+i])
+			}
+		}
+	}
+}
+
+func Test_scopePipeline(t *testing.T) {
+	var buf, errs bytes.Buffer
+	l := newTestFlog(&buf, LOG_LOCAL0|LOG_INFO, "test")
+	l.SetFormatter(CompactFormatter{})
+	route := newTestFlog(&errs, LOG_LOCAL0|LOG_INFO, "test")
+	route.SetFormatter(CompactFormatter{})
+	l.SetSeverityRoute(LOG_ERR, route)
+	l.SetMaxLineBytes(10)
+
+	var fired []string
+	l.AddHook(HookFunc(func(p Priority, msg string, _ time.Time) error {
+		fired = append(fired, msg)
+		return nil
+	}))
+
+	s := l.BeginScope()
+	s.Info("first line")
+	s.Err("routed")
+	s.Info("b")
+	if buf.Len() != 0 || len(fired) != 0 {
+		t.Fatalf("Expect: buffered until EndScope, get:%q %v", buf.String(), fired)
+	}
+	if err := s.EndScope(); err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+
+	// 经过截断、路由、统计和钩子
+	if s := buf.String(); s != "6|first li\n6|b\n" {
+		t.Errorf("Expect: truncated scope lines, get:%q", s)
+	}
+	if s := errs.String(); s != "3|routed\n" {
+		t.Errorf("Expect: routed, get:%q", s)
+	}
+	if st := l.Stats(); st.Written[LOG_INFO] != 2 || st.TruncatedBytes == 0 {
+		t.Errorf("Expect: stats counted, get:%+v", st)
+	}
+	if strings.Join(fired, ",") != "first line,routed,b" {
+		t.Errorf("Expect: hooks fired, get:%v", fired)
+	}
+}
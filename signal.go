@@ -0,0 +1,43 @@
+package flog
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// ReopenOnSignal 收到sigs(默认SIGHUP)时调用Reopen，配合logrotate使用。
+// 返回的函数停止监听，日志Close后也会停止
+func (w *Flog) ReopenOnSignal(sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGHUP}
+	}
+
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, sigs...)
+
+	quit := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		defer signal.Stop(c)
+
+		for {
+			select {
+			case <-c:
+				w.Reopen()
+			case <-quit:
+				return
+			case <-w.done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(quit) })
+		<-exited
+	}
+}
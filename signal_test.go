@@ -0,0 +1,78 @@
+//go:build unix
+
+package flog
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func Test_reopenOnSignal(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.log")
+
+	l, err := File(file, LOG_LOCAL0|LOG_INFO, "test")
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	defer l.Close()
+	l.SetFormatter(CompactFormatter{})
+
+	stop := l.ReopenOnSignal(syscall.SIGUSR1)
+	defer stop()
+
+	l.Info("before")
+	os.Rename(file, file+".1")
+	syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !exists(file) && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	l.Info("after")
+	if s := readFile(t, file); s != "6|after\n" {
+		t.Errorf("Expect:%q, get:%q", "6|after\n", s)
+	}
+	if s := readFile(t, file+".1"); s != "6|before\n" {
+		t.Errorf("Expect:%q, get:%q", "6|before\n", s)
+	}
+}
+
+func Test_toggleLevelOnSignal(t *testing.T) {
+	r := NewRecorder()
+	r.SetLevel("info")
+
+	if _, err := r.ToggleLevelOnSignal("info", "nope"); err == nil {
+		t.Errorf("Expect: unknown severity")
+	}
+
+	stop, err := r.ToggleLevelOnSignal("info", "debug")
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	defer stop()
+
+	wait := func(sig syscall.Signal, expect Priority) {
+		syscall.Kill(os.Getpid(), sig)
+		deadline := time.Now().Add(2 * time.Second)
+		for time.Now().Before(deadline) {
+			if _, f, _ := r.level(); f == expect {
+				return
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		t.Errorf("Expect:%v after %v", severityNames[expect], sig)
+	}
+
+	wait(syscall.SIGUSR1, LOG_DEBUG)
+	wait(syscall.SIGUSR1, LOG_INFO)
+	wait(syscall.SIGUSR1, LOG_DEBUG)
+	wait(syscall.SIGUSR2, LOG_INFO)
+
+	if p := r.getPriority(); p&facilityMask != LOG_LOCAL0 {
+		t.Errorf("Expect: facility unchanged, get:%v", p)
+	}
+}
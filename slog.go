@@ -0,0 +1,140 @@
+package flog
+
+import (
+	"context"
+	"log/slog"
+)
+
+// SlogOptions 是NewSlogHandler的配置
+type SlogOptions struct {
+	// Level 最低输出级别，nil时为slog.LevelInfo
+	Level slog.Leveler
+}
+
+type slogHandler struct {
+	w      Writer
+	opts   SlogOptions
+	fields []Field
+	prefix string
+}
+
+// NewSlogHandler 返回写入w的slog.Handler，slog级别对应syslog级别：
+// Debug为LOG_DEBUG，Info为LOG_INFO，Info+2为LOG_NOTICE，Warn为LOG_WARNING，
+// Error为LOG_ERR，Error+4为LOG_CRIT，Error+8为LOG_ALERT。
+// w是*Flog或*Entry时属性作为字段交给Formatter(JSON格式时合并到对象中)，
+// 否则以key=value追加到消息后
+func NewSlogHandler(w Writer, opts *SlogOptions) slog.Handler {
+	h := &slogHandler{w: w}
+	if opts != nil {
+		h.opts = *opts
+	}
+	return h
+}
+
+func slogPriority(l slog.Level) Priority {
+	switch {
+	case l < slog.LevelInfo:
+		return LOG_DEBUG
+	case l < slog.LevelInfo+2:
+		return LOG_INFO
+	case l < slog.LevelWarn:
+		return LOG_NOTICE
+	case l < slog.LevelError:
+		return LOG_WARNING
+	case l < slog.LevelError+4:
+		return LOG_ERR
+	case l < slog.LevelError+8:
+		return LOG_CRIT
+	}
+	return LOG_ALERT
+}
+
+func (h *slogHandler) Enabled(_ context.Context, l slog.Level) bool {
+	min := slog.LevelInfo
+	if h.opts.Level != nil {
+		min = h.opts.Level.Level()
+	}
+	if l < min {
+		return false
+	}
+
+	if e, ok := h.w.(interface{ enabled(Priority) bool }); ok {
+		return e.enabled(slogPriority(l))
+	}
+	return true
+}
+
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	fields := make([]Field, 0, len(h.fields)+r.NumAttrs())
+	fields = append(fields, h.fields...)
+	switch w := h.w.(type) {
+	case *Flog:
+		fields = append(fields, w.contextFields(ctx)...)
+	case *Entry:
+		fields = append(fields, w.l.contextFields(ctx)...)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		fields = appendAttr(fields, h.prefix, a)
+		return true
+	})
+
+	p := slogPriority(r.Level)
+	rec := &Record{Priority: p, Time: r.Time, Msg: r.Message, Fields: fields, pc: r.PC}
+
+	switch w := h.w.(type) {
+	case *Flog:
+		_, err := w.logRecord(rec)
+		return err
+	case *Entry:
+		rec.Fields = append(append([]Field(nil), w.fields...), fields...)
+		rec.SD = w.sd
+		_, err := w.l.logRecord(rec)
+		return err
+	}
+
+	b := []byte(r.Message)
+	for _, f := range fields {
+		b = append(b, ' ')
+		b = appendField(b, f)
+	}
+	return writeTo(h.w, p, string(b))
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	n := *h
+	n.fields = make([]Field, 0, len(h.fields)+len(attrs))
+	n.fields = append(n.fields, h.fields...)
+	for _, a := range attrs {
+		n.fields = appendAttr(n.fields, h.prefix, a)
+	}
+	return &n
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	n := *h
+	n.prefix = h.prefix + name + "."
+	return &n
+}
+
+// appendAttr 展开属性，组中的key以 组名.key 表示
+func appendAttr(fields []Field, prefix string, a slog.Attr) []Field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, g := range a.Value.Group() {
+			fields = appendAttr(fields, prefix, g)
+		}
+		return fields
+	}
+
+	return append(fields, Field{prefix + a.Key, a.Value.Any()})
+}
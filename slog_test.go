@@ -0,0 +1,79 @@
+package flog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_slogHandler(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestFlog(&buf, LOG_LOCAL0|LOG_DEBUG, "test")
+	l.SetFormatter(CompactFormatter{})
+
+	log := slog.New(NewSlogHandler(l, &SlogOptions{Level: slog.LevelDebug}))
+	log.Debug("debug", "n", 1)
+	log.Info("info", slog.Group("req", "id", "abc", "ms", 12))
+	log.Warn("warn")
+	log.Error("error", "err", errors.New("boom"))
+	log.Log(context.Background(), slog.LevelError+4, "crit")
+	log.With("user", "bob").WithGroup("g").Info("grouped", "k", "v")
+
+	expect := "7|debug n=1\n" +
+		"6|info req.id=abc req.ms=12\n" +
+		"4|warn\n" +
+		"3|error err=boom\n" +
+		"2|crit\n" +
+		"6|grouped user=bob g.k=v\n"
+	if buf.String() != expect {
+		t.Errorf("Expect:%q, get:%q", expect, buf.String())
+	}
+}
+
+func Test_slogLevel(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestFlog(&buf, LOG_LOCAL0|LOG_NOTICE, "test")
+	l.SetFormatter(CompactFormatter{})
+
+	log := slog.New(NewSlogHandler(l, nil))
+	if log.Enabled(context.Background(), slog.LevelDebug) {
+		t.Errorf("Expect: debug disabled by default")
+	}
+	if log.Enabled(context.Background(), slog.LevelInfo) {
+		t.Errorf("Expect: info filtered by the logger")
+	}
+	log.Log(context.Background(), slog.LevelInfo+2, "notice")
+	if s := buf.String(); s != "5|notice\n" {
+		t.Errorf("Expect:%q, get:%q", "5|notice\n", s)
+	}
+}
+
+func Test_slogJSON(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestFlog(&buf, LOG_LOCAL0|LOG_INFO, "test")
+	l.SetFormat(FormatJSON)
+
+	tm := time.Date(2020, 3, 4, 5, 6, 7, 0, time.UTC)
+	h := NewSlogHandler(l.With("svc", "api"), nil)
+	r := slog.NewRecord(tm, slog.LevelInfo, "json", 0)
+	r.AddAttrs(slog.Int("n", 1))
+	h.Handle(context.Background(), r)
+
+	s := buf.String()
+	if !strings.HasPrefix(s, `{"time":"2020-03-04T05:06:07Z","severity":"info"`) || !strings.HasSuffix(s, `"msg":"json","svc":"api","n":1}`+"\n") {
+		t.Errorf("Expect: record time and merged fields, get:%s", s)
+	}
+}
+
+func Test_slogWriter(t *testing.T) {
+	w := &writerOnly{new(bytes.Buffer)}
+	slog.New(NewSlogHandler(w, nil)).Warn("plain", "k", "a b")
+
+	if s := w.buf.String(); s != `warning plain k="a b"` {
+		t.Errorf("Expect:%q, get:%q", `warning plain k="a b"`, s)
+	}
+}
@@ -0,0 +1,223 @@
+package flog
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+)
+
+// 补发时一次写入的上限
+const spoolMaxBatch = 64 * 1024
+
+// 文件头是8字节的补发位置，之后每条日志是4字节长度加内容
+const spoolHeader = 8
+
+var errSpoolFull = errors.New("flog: spool full")
+
+// WithSpool 网络日志发送失败时把日志按顺序存入dir下的文件，最多maxSize字节(0不限)，
+// 存满后丢弃新的日志。连接恢复后的下一次写入会先按顺序补发存下的日志，
+// 日志的时间是原来的时间。进程重启后继续补发上次没有发出的日志。
+// 开启后DialNet连接失败也不返回错误
+func WithSpool(dir string, maxSize int64) Option {
+	return func(w *Flog) {
+		w.opts.net.spoolDir = dir
+		w.opts.net.spoolMax = maxSize
+	}
+}
+
+// spool 是磁盘上的先进先出队列，只由netWriter在持有写锁时使用
+type spool struct {
+	f    *os.File
+	max  int64
+	size int64 // 文件大小
+	off  int64 // 下一条待补发日志的位置
+
+	dropped atomic.Uint64
+}
+
+// openSpool 打开network、raddr对应的队列文件，不同的目标使用不同的文件
+func openSpool(dir, network, raddr string, max int64) (*spool, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	name := network + "-" + strings.NewReplacer(":", "_", "/", "_", "\\", "_").Replace(raddr) + ".spool"
+	f, err := os.OpenFile(filepath.Join(dir, name), os.O_RDWR|os.O_CREATE, 0666)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &spool{f: f, max: max}
+	if err := s.load(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *spool) load() error {
+	fi, err := s.f.Stat()
+	if err != nil {
+		return err
+	}
+	if fi.Size() < spoolHeader {
+		return s.reset()
+	}
+
+	var h [spoolHeader]byte
+	if _, err := s.f.ReadAt(h[:], 0); err != nil {
+		return err
+	}
+	s.size = fi.Size()
+	s.off = int64(binary.BigEndian.Uint64(h[:]))
+	if s.off < spoolHeader || s.off > s.size {
+		s.off = spoolHeader
+	}
+	return nil
+}
+
+// reset 清空队列
+func (s *spool) reset() error {
+	if err := s.f.Truncate(0); err != nil {
+		return err
+	}
+	s.size, s.off = spoolHeader, spoolHeader
+	return s.writeOffset()
+}
+
+func (s *spool) writeOffset() error {
+	var h [spoolHeader]byte
+	binary.BigEndian.PutUint64(h[:], uint64(s.off))
+	_, err := s.f.WriteAt(h[:], 0)
+	return err
+}
+
+func (s *spool) pending() bool {
+	return s.off < s.size
+}
+
+// put 在队列末尾追加一条日志
+func (s *spool) put(msg []byte) error {
+	n := int64(4 + len(msg))
+	if s.max > 0 && s.size-s.off+n > s.max {
+		s.dropped.Add(1)
+		return errSpoolFull
+	}
+
+	b := make([]byte, 4, n)
+	binary.BigEndian.PutUint32(b, uint32(len(msg)))
+	b = append(b, msg...)
+	if _, err := s.f.WriteAt(b, s.size); err != nil {
+		s.dropped.Add(1)
+		return err
+	}
+	s.size += n
+	return nil
+}
+
+// peek 从补发位置开始读出总长度不超过limit的日志(至少一条)，返回日志和读完后的位置
+func (s *spool) peek(limit int) ([][]byte, int64, error) {
+	var msgs [][]byte
+	off, total := s.off, 0
+
+	for off < s.size {
+		var h [4]byte
+		if _, err := s.f.ReadAt(h[:], off); err != nil {
+			return msgs, off, s.truncated(off, err)
+		}
+		n := int(binary.BigEndian.Uint32(h[:]))
+		if len(msgs) > 0 && total+n > limit {
+			break
+		}
+
+		msg := make([]byte, n)
+		if _, err := s.f.ReadAt(msg, off+4); err != nil {
+			return msgs, off, s.truncated(off, err)
+		}
+		msgs = append(msgs, msg)
+		off += int64(4 + n)
+		total += n
+	}
+	return msgs, off, nil
+}
+
+// truncated 处理进程崩溃时写了一半的最后一条日志：丢弃它
+func (s *spool) truncated(off int64, err error) error {
+	if err != io.EOF && err != io.ErrUnexpectedEOF {
+		return err
+	}
+	s.size = off
+	return s.f.Truncate(off)
+}
+
+// commit 标记off之前的日志已经补发，全部补发后清空文件
+func (s *spool) commit(off int64) error {
+	s.off = off
+	if s.off >= s.size {
+		return s.reset()
+	}
+	return s.writeOffset()
+}
+
+func (s *spool) Close() error {
+	return s.f.Close()
+}
+
+// replay 按顺序补发队列中的日志，失败时保留没有发出的
+func (n *netWriter) replay() error {
+	for n.spool.pending() {
+		msgs, off, err := n.spool.peek(spoolMaxBatch)
+		if err != nil {
+			return err
+		}
+
+		if n.stream {
+			// 流式连接可以把多条合并为一次写入
+			var b []byte
+			for _, m := range msgs {
+				b = n.frame(b, m)
+			}
+			if err := n.send(b); err != nil {
+				return err
+			}
+		} else {
+			for i, m := range msgs {
+				if err := n.send(m); err != nil {
+					// 已经发出的不再重发
+					if i > 0 {
+						n.spool.commit(n.spool.off + n.spoolLen(msgs[:i]))
+					}
+					return err
+				}
+			}
+		}
+
+		if err := n.spool.commit(off); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (n *netWriter) spoolLen(msgs [][]byte) int64 {
+	var total int64
+	for _, m := range msgs {
+		total += int64(4 + len(m))
+	}
+	return total
+}
+
+// spoolWrite 在开启WithSpool时写入msg：先补发积压的日志，失败时存入队列
+func (n *netWriter) spoolWrite(msg []byte) error {
+	if err := n.replay(); err == nil {
+		n.buf = n.frame(n.buf[:0], msg)
+		if err = n.send(n.buf); err == nil {
+			return nil
+		}
+	}
+	return n.spool.put(msg)
+}
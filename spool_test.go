@@ -0,0 +1,139 @@
+package flog
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_spool(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := openSpool(dir, "tcp", "127.0.0.1:514", 0)
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	for _, m := range []string{"a", "bb", "ccc"} {
+		if err := s.put([]byte(m)); err != nil {
+			t.Errorf("Expect:nil, get:%v", err)
+		}
+	}
+
+	msgs, off, err := s.peek(3)
+	if err != nil || len(msgs) != 2 || string(msgs[0]) != "a" || string(msgs[1]) != "bb" {
+		t.Fatalf("Expect: a bb, get:%q %v", msgs, err)
+	}
+	s.commit(off)
+	s.Close()
+
+	// 重新打开后从上次的位置继续
+	s, err = openSpool(dir, "tcp", "127.0.0.1:514", 0)
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	msgs, off, _ = s.peek(spoolMaxBatch)
+	if len(msgs) != 1 || string(msgs[0]) != "ccc" {
+		t.Errorf("Expect: ccc, get:%q", msgs)
+	}
+	s.commit(off)
+	if s.pending() {
+		t.Errorf("Expect: empty")
+	}
+	if fi, _ := s.f.Stat(); fi.Size() != spoolHeader {
+		t.Errorf("Expect: truncated to %d, get:%d", spoolHeader, fi.Size())
+	}
+
+	// 写了一半的最后一条被丢弃
+	s.put([]byte("ok"))
+	s.f.WriteAt([]byte{0, 0, 0, 9, 'x'}, s.size)
+	s.size += 5
+	msgs, off, err = s.peek(spoolMaxBatch)
+	if err != nil || len(msgs) != 1 || string(msgs[0]) != "ok" {
+		t.Errorf("Expect: ok, get:%q %v", msgs, err)
+	}
+	s.commit(off)
+	s.Close()
+
+	s, _ = openSpool(dir, "udp", "[::1]:514", 10)
+	defer s.Close()
+	if err := s.put([]byte("123456")); err != nil {
+		t.Errorf("Expect:nil, get:%v", err)
+	}
+	if err := s.put([]byte("x")); err != errSpoolFull {
+		t.Errorf("Expect:%v, get:%v", errSpoolFull, err)
+	}
+	if n := s.dropped.Load(); n != 1 {
+		t.Errorf("Expect: 1 dropped, get:%d", n)
+	}
+}
+
+func Test_spoolReplay(t *testing.T) {
+	var out syncBuffer
+	done := make(chan struct{})
+	down := true
+
+	nw := newNetWriter("tcp", "collector:514", netOptions{minBackoff: time.Nanosecond, maxBackoff: time.Nanosecond})
+	nw.dial = func() (net.Conn, error) {
+		if down {
+			return nil, errors.New("connection refused")
+		}
+		c, srv := net.Pipe()
+		go func() {
+			io.Copy(&out, srv)
+			close(done)
+		}()
+		return c, nil
+	}
+
+	s, err := openSpool(t.TempDir(), "tcp", "collector:514", 0)
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	nw.spool = s
+
+	for _, m := range []string{"first\n", "second\n"} {
+		if n, err := nw.Write([]byte(m)); err != nil || n != len(m) {
+			t.Errorf("Expect: spooled, get:%d %v", n, err)
+		}
+	}
+	if !s.pending() {
+		t.Errorf("Expect: pending")
+	}
+
+	down = false
+	nw.Write([]byte("third\n"))
+	nw.Close()
+	<-done
+
+	if s := out.String(); s != "5 first6 second5 third" {
+		t.Errorf("Expect:%q, get:%q", "5 first6 second5 third", s)
+	}
+}
+
+func Test_dialNetSpool(t *testing.T) {
+	dir := t.TempDir()
+
+	l, err := DialNet("tcp", "127.0.0.1:1", LOG_LOCAL0|LOG_INFO, "app", WithFormat(FormatCompact), WithSpool(dir, 16), WithDialTimeout(100*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Expect: spool instead of error, get:%v", err)
+	}
+	l.now = (&fakeClock{t: time.Date(2020, 3, 4, 5, 6, 7, 0, time.UTC)}).now
+
+	if err := l.Info("hello"); err != nil {
+		t.Errorf("Expect:nil, get:%v", err)
+	}
+	l.Info("this one does not fit")
+	if n := l.Stats().SpoolDropped; n != 1 {
+		t.Errorf("Expect: 1 dropped, get:%d", n)
+	}
+	l.Close()
+
+	data, _ := os.ReadFile(dir + "/tcp-127.0.0.1_1.spool")
+	if !strings.Contains(string(data), "6|hello") {
+		t.Errorf("Expect: hello kept on disk, get:%q", data)
+	}
+}
@@ -0,0 +1,94 @@
+package flog
+
+import (
+	"sync/atomic"
+)
+
+// spscRing 是单生产者单消费者的无锁环形队列，
+// 供WithAsyncSingleProducer的异步写入使用，避免channel的开销。
+// 多个生产者时必须由调用方加锁，Flog写入异步队列时总是持有mu
+type spscRing struct {
+	buf    []asyncRecord
+	mask   uint64
+	head   atomic.Uint64 // 下一个读取位置，只由消费者修改
+	tail   atomic.Uint64 // 下一个写入位置，只由生产者修改
+	closed atomic.Bool
+	wake   chan struct{} // 有新数据或已关闭时通知消费者
+	space  chan struct{} // 有空位时通知生产者
+}
+
+func newSPSCRing(size int) *spscRing {
+	n := 1
+	for n < size {
+		n <<= 1
+	}
+
+	return &spscRing{
+		buf:   make([]asyncRecord, n),
+		mask:  uint64(n - 1),
+		wake:  make(chan struct{}, 1),
+		space: make(chan struct{}, 1),
+	}
+}
+
+func notify(c chan struct{}) {
+	select {
+	case c <- struct{}{}:
+	default:
+	}
+}
+
+// tryPush 在队列满时返回false
+func (r *spscRing) tryPush(v asyncRecord) bool {
+	t := r.tail.Load()
+	if t-r.head.Load() > r.mask {
+		return false
+	}
+
+	r.buf[t&r.mask] = v
+	r.tail.Store(t + 1)
+	notify(r.wake)
+	return true
+}
+
+// push 在队列满时等待消费者
+func (r *spscRing) push(v asyncRecord) {
+	for !r.tryPush(v) {
+		<-r.space
+	}
+}
+
+func (r *spscRing) pop() (asyncRecord, bool) {
+	h := r.head.Load()
+	if h == r.tail.Load() {
+		return asyncRecord{}, false
+	}
+
+	v := r.buf[h&r.mask]
+	r.buf[h&r.mask] = asyncRecord{}
+	r.head.Store(h + 1)
+	notify(r.space)
+	return v, true
+}
+
+// wait 等待新数据，队列已关闭且为空时返回false
+func (r *spscRing) wait() bool {
+	for r.len() == 0 {
+		if r.closed.Load() {
+			// 关闭前放入的数据此时一定可见
+			return r.len() > 0
+		}
+		<-r.wake
+	}
+	return true
+}
+
+// close 在所有push完成后调用
+func (r *spscRing) close() {
+	r.closed.Store(true)
+	notify(r.wake)
+}
+
+func (r *spscRing) len() int {
+	return int(r.tail.Load() - r.head.Load())
+}
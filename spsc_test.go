@@ -0,0 +1,170 @@
+package flog
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func Test_spscRingOrder(t *testing.T) {
+	r := newSPSCRing(8)
+	const n = 10000
+
+	go func() {
+		for i := 0; i < n; i++ {
+			r.push(asyncRecord{b: []byte(strconv.Itoa(i))})
+		}
+	}()
+
+	for i := 0; i < n; {
+		v, ok := r.pop()
+		if !ok {
+			r.wait()
+			continue
+		}
+		if string(v.b) != strconv.Itoa(i) {
+			t.Fatalf("Expect:%d, get:%s", i, v.b)
+		}
+		i++
+	}
+
+	if r.len() != 0 {
+		t.Errorf("Expect:0, get:%d", r.len())
+	}
+}
+
+func Test_spscRingFull(t *testing.T) {
+	r := newSPSCRing(3)
+
+	for i := 0; i < 4; i++ {
+		if !r.tryPush(asyncRecord{b: []byte("x")}) {
+			t.Fatalf("Expect: push %d ok", i)
+		}
+	}
+	if r.tryPush(asyncRecord{b: []byte("x")}) {
+		t.Errorf("Expect: full ring")
+	}
+}
+
+var benchLine = []byte("<134>Jan  2 03:04:05 bench[1]: benchmark message\n")
+
+func Benchmark_spscRing(b *testing.B) {
+	r := newSPSCRing(1024)
+	done := make(chan struct{})
+
+	go func() {
+		for i := 0; i < b.N; {
+			if _, ok := r.pop(); ok {
+				i++
+				continue
+			}
+			r.wait()
+		}
+		close(done)
+	}()
+
+	for i := 0; i < b.N; i++ {
+		r.push(asyncRecord{b: benchLine})
+	}
+	<-done
+}
+
+func Benchmark_spscChannel(b *testing.B) {
+	ch := make(chan asyncRecord, 1024)
+	done := make(chan struct{})
+
+	go func() {
+		for range ch {
+		}
+		close(done)
+	}()
+
+	for i := 0; i < b.N; i++ {
+		ch <- asyncRecord{b: benchLine}
+	}
+	close(ch)
+	<-done
+}
+
+func Test_asyncSingleProducer(t *testing.T) {
+	var buf syncBuffer
+	l, err := NewFromWriter(nopCloser{&buf}, LOG_LOCAL0|LOG_INFO, LOG_INFO, "test",
+		WithFormat(FormatCompact), WithAsync(4), WithAsyncSingleProducer())
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	if l.async.ring == nil {
+		t.Fatalf("Expect: ring queue")
+	}
+
+	var expect strings.Builder
+	for i := 0; i < 1000; i++ {
+		l.Info(strconv.Itoa(i))
+		expect.WriteString("6|" + strconv.Itoa(i) + "\n")
+		if i == 500 {
+			if err := l.Flush(); err != nil {
+				t.Errorf("Expect:nil, get:%v", err)
+			}
+		}
+	}
+	l.Close()
+
+	if s := buf.String(); s != expect.String() {
+		t.Errorf("Expect: 1000 lines in order, get:%d bytes", len(s))
+	}
+	if err := l.Info("after"); err != ErrClosed {
+		t.Errorf("Expect:%v, get:%v", ErrClosed, err)
+	}
+
+	// OverflowDropOldest需要channel
+	l, _ = NewFromWriter(nopCloser{&buf}, LOG_LOCAL0|LOG_INFO, LOG_INFO, "test",
+		WithAsync(4), WithAsyncSingleProducer(), WithOverflow(OverflowDropOldest))
+	defer l.Close()
+	if l.async.ring != nil {
+		t.Errorf("Expect: channel queue with OverflowDropOldest")
+	}
+}
+
+func Test_asyncSingleProducerDropNew(t *testing.T) {
+	sw := newSlowWriter()
+	l, _ := NewFromWriter(sw, LOG_LOCAL0|LOG_INFO, LOG_INFO, "test",
+		WithFormat(FormatCompact), WithAsync(2), WithAsyncSingleProducer(), WithOverflow(OverflowDropNew))
+
+	l.Info("1")
+	<-sw.entered
+	for _, m := range []string{"2", "3", "4"} {
+		l.Info(m)
+	}
+	close(sw.release)
+	l.Close()
+
+	if s := sw.String(); s != "6|1\n6|2\n6|3\n" {
+		t.Errorf("Expect:%q, get:%q", "6|1\n6|2\n6|3\n", s)
+	}
+	if n := l.Dropped(); n != 1 {
+		t.Errorf("Expect:1, get:%d", n)
+	}
+}
+
+func benchmarkAsync(b *testing.B, opts ...Option) {
+	opts = append([]Option{WithFormat(FormatCompact), WithAsync(1024)}, opts...)
+	l, err := NewFromWriter(discard{}, LOG_LOCAL0|LOG_INFO, LOG_INFO, "bench", opts...)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer l.Close()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Info("benchmark message")
+	}
+	l.Flush()
+}
+
+func Benchmark_asyncChannel(b *testing.B) {
+	benchmarkAsync(b)
+}
+
+func Benchmark_asyncSingleProducer(b *testing.B) {
+	benchmarkAsync(b, WithAsyncSingleProducer())
+}
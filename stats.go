@@ -0,0 +1,50 @@
+package flog
+
+import (
+	"time"
+)
+
+// Stats 是日志自身的运行统计
+type Stats struct {
+	Written         [8]uint64 // 按severity统计写入的条数
+	Dropped         [8]uint64 // 按severity统计被SetRateLimit丢弃或写入失败的条数
+	BytesWritten    uint64    // 写入输出的字节数
+	WriteErrors     uint64    // 写入输出失败的次数
+	AsyncDropped    uint64    // 异步队列满时丢弃的条数，见Dropped
+	QueueDepth      int       // 异步队列中等待写入的条数
+	Buffered        int       // WithBuffer缓存中还没有写出的字节数
+	LastRotation    time.Time // 最后一次轮转或按时间切换文件的时间，没有时为零值
+	TruncatedBytes  uint64    // SetMaxLineBytes截掉的字节数
+	FormatterPanics uint64    // Formatter发生panic的次数
+	HookErrors      uint64    // Hook.Fire返回错误的次数
+	SpoolDropped    uint64    // WithSpool的队列存满或写入失败时丢弃的日志条数
+}
+
+func (w *Flog) Stats() Stats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	s := w.stats
+	s.FormatterPanics = w.fmtPanics.Load()
+	s.HookErrors = w.hookErrs.Load()
+	if w.spool != nil {
+		s.SpoolDropped = w.spool.dropped.Load()
+	}
+	if w.async != nil {
+		s.AsyncDropped = w.async.dropped.Load()
+		s.QueueDepth = w.async.len()
+	}
+
+	if fw := w.file; fw != nil {
+		if t := fw.rotated.Load(); t != 0 {
+			s.LastRotation = time.Unix(0, t)
+		}
+
+		unlock := w.lockFile()
+		if fw.buf != nil {
+			s.Buffered = fw.buf.Buffered()
+		}
+		unlock()
+	}
+	return s
+}
@@ -0,0 +1,12 @@
+package flog
+
+import (
+	"os"
+	"syscall"
+)
+
+// redirectStderr 让文件描述符2指向f，runtime的panic输出也会写入f
+func redirectStderr(f *os.File) error {
+	// arm64等平台没有dup2
+	return syscall.Dup3(int(f.Fd()), 2, 0)
+}
@@ -0,0 +1,11 @@
+//go:build !linux && !aix && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+
+package flog
+
+import (
+	"os"
+)
+
+func redirectStderr(f *os.File) error {
+	return ErrNotSupported
+}
@@ -0,0 +1,13 @@
+//go:build aix || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package flog
+
+import (
+	"os"
+	"syscall"
+)
+
+// redirectStderr 让文件描述符2指向f，runtime的panic输出也会写入f
+func redirectStderr(f *os.File) error {
+	return syscall.Dup2(int(f.Fd()), 2)
+}
@@ -0,0 +1,58 @@
+package flog
+
+import (
+	"io"
+	"log"
+	"strings"
+)
+
+type levelWriter struct {
+	l *Flog
+	p Priority
+}
+
+// Write 每次调用写一条日志，去掉末尾的换行
+func (lw levelWriter) Write(b []byte) (int, error) {
+	if _, err := lw.l.writeAndRetry(lw.p, strings.TrimSuffix(string(b), "\n")); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// LevelWriter 返回以level级别写入日志的io.Writer，供只接受io.Writer的库使用
+func (w *Flog) LevelWriter(level Priority) io.Writer {
+	return levelWriter{w, level}
+}
+
+// StdLogger 返回以level级别写入日志的*log.Logger。
+// 时间由日志自己输出，log.Logger不再加时间前缀
+func (w *Flog) StdLogger(level Priority) *log.Logger {
+	return log.New(w.LevelWriter(level), "", 0)
+}
+
+type writerLevel struct {
+	w Writer
+	p Priority
+}
+
+func (lw writerLevel) Write(b []byte) (int, error) {
+	if err := writeTo(lw.w, lw.p, strings.TrimSuffix(string(b), "\n")); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// NewLevelWriter 返回以severity级别写入w的io.Writer，每次Write写一条日志。
+// facility和tag使用w自己的设置
+func NewLevelWriter(w Writer, severity Priority) io.Writer {
+	if l, ok := w.(*Flog); ok {
+		return l.LevelWriter(severity)
+	}
+	return writerLevel{w, severity}
+}
+
+// StdLogger 返回以severity级别写入w的*log.Logger，
+// 用于只接受*log.Logger的库，如http.Server的ErrorLog
+func StdLogger(w Writer, severity Priority) *log.Logger {
+	return log.New(NewLevelWriter(w, severity), "", 0)
+}
@@ -0,0 +1,59 @@
+package flog
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+func Test_stdLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestFlog(&buf, LOG_LOCAL0|LOG_INFO, "test")
+	l.now = (&fakeClock{t: time.Date(2020, 3, 4, 5, 6, 7, 0, time.UTC)}).now
+
+	std := l.StdLogger(LOG_WARNING)
+	std.Printf("disk %d%% full", 90)
+	std.Println("second")
+
+	pid := os.Getpid()
+	expect := fmt.Sprintf("Mar  4 05:06:07 WARNING test[%d]: disk 90%% full\n", pid) +
+		fmt.Sprintf("Mar  4 05:06:07 WARNING test[%d]: second\n", pid)
+	if buf.String() != expect {
+		t.Errorf("Expect:%q, get:%q", expect, buf.String())
+	}
+}
+
+func Test_levelWriter(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestFlog(&buf, LOG_LOCAL0|LOG_NOTICE, "test")
+	l.SetFormatter(CompactFormatter{})
+
+	fmt.Fprintln(l.LevelWriter(LOG_ERR), "from library")
+	fmt.Fprintln(l.LevelWriter(LOG_DEBUG), "filtered")
+
+	if s := buf.String(); s != "3|from library\n" {
+		t.Errorf("Expect:%q, get:%q", "3|from library\n", s)
+	}
+}
+
+func Test_stdLoggerWriter(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestFlog(&buf, LOG_DAEMON|LOG_INFO, "httpd")
+	l.SetFormatter(SyslogFormatter{})
+	l.now = (&fakeClock{t: time.Date(2020, 3, 4, 5, 6, 7, 0, time.UTC)}).now
+
+	// 经过Filter包装后不再是*Flog
+	srv := &http.Server{ErrorLog: StdLogger(Filter(l, LOG_DEBUG), LOG_ERR)}
+	srv.ErrorLog.Printf("http: TLS handshake error from %s", "1.2.3.4:5")
+	StdLogger(l, LOG_NOTICE).Print("direct")
+
+	pid := os.Getpid()
+	expect := fmt.Sprintf("<27>Mar  4 05:06:07 httpd[%d]: http: TLS handshake error from 1.2.3.4:5\n", pid) +
+		fmt.Sprintf("<29>Mar  4 05:06:07 httpd[%d]: direct\n", pid)
+	if buf.String() != expect {
+		t.Errorf("Expect:%q, get:%q", expect, buf.String())
+	}
+}
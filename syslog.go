@@ -0,0 +1,18 @@
+//go:build !windows && !plan9
+
+package flog
+
+import (
+	"log/syslog"
+)
+
+// Dial 连接syslog，使用RFC3164格式(带<pri>)。
+// 返回Writer，各平台的签名相同，需要时可以断言为*syslog.Writer
+func Dial(network, raddr string, priority Priority, tag string) (Writer, error) {
+	s, err := syslog.Dial(network, raddr, syslog.Priority(priority), cleanTag(tag))
+	if err != nil {
+		// 不返回装着nil指针的Writer
+		return nil, err
+	}
+	return s, nil
+}
@@ -0,0 +1,9 @@
+//go:build windows || plan9
+
+package flog
+
+// Dial 在没有syslog的平台上总是返回ErrNotSupported，
+// New的"<syslog>"也会返回这个错误。网络地址使用DialNet，不受影响
+func Dial(network, raddr string, priority Priority, tag string) (Writer, error) {
+	return nil, ErrNotSupported
+}
@@ -0,0 +1,9 @@
+//go:build darwin || freebsd || netbsd || dragonfly
+
+package flog
+
+import (
+	"syscall"
+)
+
+const ioctlReadTermios = syscall.TIOCGETA
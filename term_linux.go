@@ -0,0 +1,7 @@
+package flog
+
+import (
+	"syscall"
+)
+
+const ioctlReadTermios = syscall.TCGETS
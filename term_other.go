@@ -0,0 +1,11 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !dragonfly && !windows
+
+package flog
+
+import (
+	"os"
+)
+
+func isatty(f *os.File) bool {
+	return false
+}
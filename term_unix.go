@@ -0,0 +1,16 @@
+//go:build linux || darwin || freebsd || netbsd || dragonfly
+
+package flog
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// isatty 用读取终端属性的ioctl判断，/dev/null等不是终端的字符设备会失败
+func isatty(f *os.File) bool {
+	var t syscall.Termios
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), ioctlReadTermios, uintptr(unsafe.Pointer(&t)))
+	return errno == 0
+}
@@ -0,0 +1,11 @@
+package flog
+
+import (
+	"os"
+	"syscall"
+)
+
+func isatty(f *os.File) bool {
+	var mode uint32
+	return syscall.GetConsoleMode(syscall.Handle(f.Fd()), &mode) == nil
+}
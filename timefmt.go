@@ -0,0 +1,72 @@
+package flog
+
+import (
+	"strings"
+	"time"
+)
+
+type timeFormat struct {
+	layout string
+	loc    *time.Location
+}
+
+// tsformat参数可以使用的预设
+var timePresets = map[string]string{
+	"stamp":       time.Stamp,
+	"stampmilli":  time.StampMilli,
+	"stampmicro":  time.StampMicro,
+	"rfc3339":     time.RFC3339,
+	"rfc3339nano": time.RFC3339Nano,
+	"datetime":    time.DateTime,
+}
+
+// timePreset 返回预设的layout和时区，不是预设时name本身就是layout。
+// rfc3339nano-utc 为RFC3339Nano格式的UTC时间
+func timePreset(name string) (string, *time.Location) {
+	n := strings.ToLower(name)
+	if n == "rfc3339nano-utc" {
+		return time.RFC3339Nano, time.UTC
+	}
+	if layout, ok := timePresets[n]; ok {
+		return layout, nil
+	}
+	return name, nil
+}
+
+func (w *Flog) setTime(f func(t *timeFormat)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var t timeFormat
+	if old := w.timeFmt.Load(); old != nil {
+		t = *old
+	}
+	f(&t)
+	w.timeFmt.Store(&t)
+}
+
+// SetTimeFormat 设置文本格式(HumanFormatter、SyslogFormatter)中时间的layout，
+// 如time.RFC3339。默认为不含年份和时区的time.Stamp，layout为空时恢复默认。
+// JSON和RFC5424格式的时间总是RFC3339
+func (w *Flog) SetTimeFormat(layout string) {
+	w.setTime(func(t *timeFormat) { t.layout = layout })
+}
+
+// SetTimeLocation 设置输出时间使用的时区，如time.UTC，nil表示使用本地时区
+func (w *Flog) SetTimeLocation(loc *time.Location) {
+	w.setTime(func(t *timeFormat) { t.loc = loc })
+}
+
+// WithTimeFormat 见SetTimeFormat
+func WithTimeFormat(layout string) Option {
+	return func(w *Flog) {
+		w.SetTimeFormat(layout)
+	}
+}
+
+// WithTimeLocation 见SetTimeLocation
+func WithTimeLocation(loc *time.Location) Option {
+	return func(w *Flog) {
+		w.SetTimeLocation(loc)
+	}
+}
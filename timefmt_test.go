@@ -0,0 +1,71 @@
+package flog
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_setTimeFormat(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestFlog(&buf, LOG_LOCAL0|LOG_INFO, "test")
+	l.now = (&fakeClock{t: time.Date(2020, 3, 4, 5, 6, 7, 123000000, time.UTC)}).now
+
+	l.SetTimeFormat(time.RFC3339)
+	l.SetTimeLocation(time.FixedZone("UTC+8", 8*3600))
+	l.Info("local")
+
+	l.SetTimeFormat("")
+	l.SetTimeLocation(nil)
+	l.Info("default")
+
+	pid := os.Getpid()
+	expect := fmt.Sprintf("2020-03-04T13:06:07+08:00 INFO test[%d]: local\n", pid) +
+		fmt.Sprintf("Mar  4 05:06:07 INFO test[%d]: default\n", pid)
+	if buf.String() != expect {
+		t.Errorf("Expect:%q, get:%q", expect, buf.String())
+	}
+}
+
+func Test_timeFormatSyslog(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestFlog(&buf, LOG_LOCAL0|LOG_INFO, "test")
+	l.now = (&fakeClock{t: time.Date(2020, 3, 4, 5, 6, 7, 123000000, time.UTC)}).now
+	l.SetFormat(FormatSyslog)
+	l.SetTimeFormat(time.StampMilli)
+
+	l.Info("milli")
+
+	expect := fmt.Sprintf("<134>Mar  4 05:06:07.123 test[%d]: milli\n", os.Getpid())
+	if buf.String() != expect {
+		t.Errorf("Expect:%q, get:%q", expect, buf.String())
+	}
+}
+
+func Test_timeQuery(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.log")
+
+	w, err := New(file+"?tsformat=rfc3339nano-utc", "", "test")
+	if err != nil {
+		t.Fatalf("Expect:nil, get:%v", err)
+	}
+	l := w.(*Flog)
+	l.now = (&fakeClock{t: time.Date(2020, 3, 4, 13, 6, 7, 5, time.FixedZone("UTC+8", 8*3600))}).now
+	l.Info("utc")
+	l.Close()
+
+	expect := fmt.Sprintf("2020-03-04T05:06:07.000000005Z INFO test[%d]: utc\n", os.Getpid())
+	if s := readFile(t, file); s != expect {
+		t.Errorf("Expect:%q, get:%q", expect, s)
+	}
+
+	if _, err := parseDSN("<stderr>?tz=Nowhere/City"); err == nil {
+		t.Errorf("Expect: invalid tz error")
+	}
+	if d, err := parseDSN("<stderr>?tsformat=rfc3339&tz=UTC&rotate=daily"); err != nil || d.scheme != "stderr" || d.addr != "" || len(d.opts) != 2 {
+		t.Errorf("Expect: <stderr> with 2 options, get:%+v %v", d, err)
+	}
+}
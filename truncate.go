@@ -0,0 +1,61 @@
+package flog
+
+import (
+	"strconv"
+	"unicode/utf8"
+)
+
+// SetMaxLineBytes 限制格式化后每行(不含换行)的最大字节数，
+// 超出的部分被截掉(按UTF-8字符边界)并追加 "(+N bytes truncated)"，
+// 截掉的字节数计入Stats。n<=0表示不限制
+func (w *Flog) SetMaxLineBytes(n int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.maxLine = n
+}
+
+func truncMarker(n int) []byte {
+	b := append([]byte(" (+"), strconv.Itoa(n)...)
+	return append(b, " bytes truncated)"...)
+}
+
+// truncateLine 返回截断后的行和截掉的字节数
+func truncateLine(b []byte, n int) ([]byte, int) {
+	body := b
+	nl := len(body) > 0 && body[len(body)-1] == '\n'
+	if nl {
+		body = body[:len(body)-1]
+	}
+
+	if len(body) <= n {
+		return b, 0
+	}
+
+	cut := n
+	var marker []byte
+	for {
+		marker = truncMarker(len(body) - cut)
+		c := n - len(marker)
+		if c < 0 {
+			// 放不下标记，直接截断
+			marker = nil
+			c = n
+		}
+		for c > 0 && !utf8.RuneStart(body[c]) {
+			c--
+		}
+		if c == cut {
+			break
+		}
+		cut = c
+	}
+
+	out := make([]byte, 0, cut+len(marker)+1)
+	out = append(out, body[:cut]...)
+	out = append(out, marker...)
+	if nl {
+		out = append(out, '\n')
+	}
+	return out, len(body) - cut
+}
@@ -0,0 +1,70 @@
+package flog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func Test_maxLineBytes(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestFlog(&buf, LOG_LOCAL0|LOG_INFO, "test")
+	l.SetFormatter(CompactFormatter{})
+	l.SetMaxLineBytes(40)
+
+	l.Info("short")
+	l.Info(strings.Repeat("x", 100))
+
+	lines := strings.Split(buf.String(), "\n")
+	if lines[0] != "6|short" {
+		t.Errorf("Expect:6|short, get:%q", lines[0])
+	}
+
+	expect := "6|" + strings.Repeat("x", 16) + " (+84 bytes truncated)"
+	if lines[1] != expect {
+		t.Errorf("Expect:%q, get:%q", expect, lines[1])
+	}
+	if len(lines[1]) > 40 {
+		t.Errorf("Expect:<=40, get:%d", len(lines[1]))
+	}
+
+	if s := l.Stats(); s.TruncatedBytes != 84 {
+		t.Errorf("Expect:84, get:%d", s.TruncatedBytes)
+	}
+}
+
+func Test_maxLineBytesDelta(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestFlog(&buf, LOG_LOCAL0|LOG_INFO, "test")
+	l.SetFormatter(CompactFormatter{})
+	l.SetDeltaPrefix(true)
+	l.SetMaxLineBytes(40)
+
+	l.Info(strings.Repeat("x", 100))
+
+	line := strings.TrimSuffix(buf.String(), "\n")
+	if !strings.HasPrefix(line, "+0.000s 6|x") || !strings.HasSuffix(line, " bytes truncated)") {
+		t.Errorf("Expect: delta prefix and marker, get:%q", line)
+	}
+	if len(line) > 40 {
+		t.Errorf("Expect:<=40 with delta prefix, get:%d %q", len(line), line)
+	}
+}
+
+func Test_truncateRuneBoundary(t *testing.T) {
+	line := []byte(strings.Repeat("中", 20) + "\n")
+
+	for n := 25; n < 40; n++ {
+		out, dropped := truncateLine(line, n)
+		if !utf8.Valid(out) {
+			t.Errorf("n=%d invalid utf8 %q", n, out)
+		}
+		if len(out)-1 > n {
+			t.Errorf("n=%d Expect:<=%d, get:%d", n, n, len(out)-1)
+		}
+		if !bytes.HasSuffix(out, []byte(" bytes truncated)\n")) || dropped%3 != 0 {
+			t.Errorf("n=%d get:%q %d", n, out, dropped)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package flog
+
+// writeTo 按p的级别调用w对应的方法
+func writeTo(w Writer, p Priority, m string) error {
+	switch p & severityMask {
+	case LOG_EMERG:
+		return w.Emerg(m)
+	case LOG_ALERT:
+		return w.Alert(m)
+	case LOG_CRIT:
+		return w.Crit(m)
+	case LOG_ERR:
+		return w.Err(m)
+	case LOG_WARNING:
+		return w.Warning(m)
+	case LOG_NOTICE:
+		return w.Notice(m)
+	case LOG_INFO:
+		return w.Info(m)
+	}
+	return w.Debug(m)
+}